@@ -1,30 +1,60 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/quarkslab/kdigger/pkg/automaticontext"
 	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/syscalls"
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/yaml"
 )
 
-// flag for the kubeconfig
-var kubeconfig string
-
 // flag for the namespace
 var namespace string
 
 // flag to activate side effects buckets
 var sideEffects bool
 
+// flag for the minimum severity failing the command
+var failOn string
+
+// flag for the verbosity of the logs, repeated to increase it
+var verbosity int
+
+// flag for the deadline of the whole scan
+var scanTimeout time.Duration
+
+// flags for the number of buckets running at the same time and to run the
+// buckets with side effects alone
+var parallel int
+var serialSideEffects bool
+
+// flags for the directory to write the results to and to overwrite its files
+var outputDir string
+var force bool
+
+// flag for the generic options of the buckets, copied to the config
+var pluginOptions map[string]string
+
+// sideEffectsGracePeriod bounds the wait for the buckets with side effects
+// still running after the deadline of the scan, so that their cleanup can
+// complete before the process exits
+var sideEffectsGracePeriod = time.Minute
+
 // output formats
 const outputHuman = "human"
 const outputJSON = "json"
+const outputYAML = "yaml"
 
 // config that will carry parameters and client for plugin init
 var pluginConfig bucket.Config
@@ -48,11 +78,29 @@ arguments.`,
 			return errors.New("missing argument")
 		}
 
+		if failOn != "" {
+			if _, err := bucket.ParseSeverity(failOn); err != nil {
+				return fmt.Errorf("invalid --fail-on flag: %w", err)
+			}
+		}
+
 		// apply default colored human only if the color flag was not set
 		if !cmd.Flags().Changed("color") && output == outputHuman {
 			pluginConfig.Color = true
 		}
 
+		if err := buckets.ValidateOptions(pluginOptions); err != nil {
+			return fmt.Errorf("invalid --option flag: %w", err)
+		}
+		pluginConfig.Options = pluginOptions
+
+		if pluginConfig.TokenReview && !sideEffects {
+			return fmt.Errorf("the %q flag has side effects as it sends the tokens to the API server, use it with the %q or %q flag", "--token-review", "--side-effects", "-s")
+		}
+		if pluginConfig.ServicesProbe && !sideEffects {
+			return fmt.Errorf("the %q flag has side effects as it opens TCP connections to the services, use it with the %q or %q flag", "--services-probe", "--side-effects", "-s")
+		}
+
 		// check if any called buckets have side effects without the flag activated
 		for _, name := range args {
 			if buckets.HasSideEffects(name) && !sideEffects {
@@ -70,7 +118,7 @@ arguments.`,
 
 		return nil
 	},
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// handles the "all" or "a" and erase the args with the bucket list
 		// PreRun should guarantee that len(args) != 0 but in case
 		if len(args) != 0 {
@@ -90,21 +138,41 @@ arguments.`,
 
 		args = removeDuplicates(args)
 
-		// iterate through all the specified buckets
-		// TODO: some plugins might be slow, for example network scanners, so it
-		// might be a good idea in the future to parallelize the launch of these
-		// plugins
+		if outputDir != "" {
+			if err := prepareOutputDir(args); err != nil {
+				return err
+			}
+		}
+
+		var maxSeverity bucket.Severity
+		var bucketFailed bool
+		var index []indexEntry
+
+		ctx := cmd.Context()
+		if scanTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, scanTimeout)
+			defer cancel()
+		}
+
+		pluginConfig.Logger = newLogger(verbosity)
+		// the namespace flag takes precedence over the one of the context,
+		// also for the buckets loading the client themselves
+		pluginConfig.Namespace = namespace
+
+		// initialize all the specified buckets before running any of them,
+		// since loading the context modifies the shared configuration
+		jobs := make([]*bucketJob, 0, len(args))
 		for _, name := range args {
-			// initialize the bucket
+			job := &bucketJob{name: name, done: make(chan struct{})}
+			jobs = append(jobs, job)
 			if buckets.RequiresClient(name) {
 				err := loadContext(&pluginConfig)
 				if err != nil {
 					// loading the context failed and is required so skip this
-					// execution after printing the error with the name
-					err := printError(fmt.Errorf("failed loading context to initialize client: %w", err), name)
-					if err != nil {
-						return err
-					}
+					// execution, the error is printed with the name
+					job.err = fmt.Errorf("failed loading context to initialize client: %w", err)
+					close(job.done)
 					continue
 				}
 			}
@@ -112,25 +180,184 @@ arguments.`,
 			if err != nil {
 				return err
 			}
+			job.bucket = b
+			job.sideEffects = buckets.HasSideEffects(name)
+			job.finished = make(chan struct{})
+		}
 
-			// run the bucket
-			results, err := b.Run()
+		// run the buckets with a bounded parallelism, some plugins are slow,
+		// like network scanners, while others are resource heavy
+		schedule(ctx, jobs)
+
+		// report the results in the order of the arguments as they complete
+		for _, job := range jobs {
+			<-job.done
+			results, err := job.results, job.err
+			// the results of the buckets interrupted by the deadline of the
+			// scan are replaced by a comment, the deadlines of the buckets
+			// themselves are reported as regular errors
+			if errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				res := bucket.NewResults(canonicalName(job.name))
+				res.AddComment(fmt.Sprintf("The bucket timed out, the %s deadline of the scan was reached before it completed.", scanTimeout))
+				results, err = *res, nil
+				bucketFailed = true
+			}
 			if err != nil {
-				err := printError(err, name)
-				if err != nil {
-					return err
-				}
+				bucketFailed = true
 			} else {
-				err = printResults(results, bucket.ResultsOpts{OutputWidth: outputWidth})
-				if err != nil {
-					return err
-				}
+				maxSeverity = max(maxSeverity, results.Severity())
+			}
+			entry, err := report(job.name, results, err)
+			if err != nil {
+				return err
+			}
+			index = append(index, entry)
+		}
+
+		// buckets with side effects abandoned at the deadline might still be
+		// cleaning up, the process must not exit under them
+		for _, name := range waitSideEffects(jobs, sideEffectsGracePeriod) {
+			pluginConfig.Logger.Warn("bucket still running after the grace period, its cleanup might be incomplete", "bucket", canonicalName(name), "gracePeriod", sideEffectsGracePeriod)
+		}
+
+		if outputDir != "" {
+			if err := writeIndex(index); err != nil {
+				return err
+			}
+		}
+
+		if failOn != "" {
+			threshold, _ := bucket.ParseSeverity(failOn)
+			if maxSeverity >= threshold {
+				exitCode |= exitCodeFindings
+			}
+			if bucketFailed {
+				exitCode |= exitCodeBucketErrors
 			}
 		}
 		return nil
 	},
 }
 
+// bucketJob is a bucket to run, done is closed once results or err are set.
+// finished is closed once the Run method really returned, which happens after
+// done for the buckets abandoned at the deadline.
+type bucketJob struct {
+	name        string
+	bucket      bucket.Interface
+	sideEffects bool
+	results     bucket.Results
+	err         error
+	done        chan struct{}
+	finished    chan struct{}
+}
+
+// schedule runs the initialized buckets of jobs in the background, at most
+// parallel of them at a time. With serialSideEffects, the buckets with side
+// effects run alone so that they don't disturb or get disturbed by others.
+func schedule(ctx context.Context, jobs []*bucketJob) {
+	limit := parallel
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
+	slots := make(chan struct{}, limit)
+	// regular buckets share the lock, exclusive buckets hold it alone
+	var exclusive sync.RWMutex
+	go func() {
+		for _, job := range jobs {
+			if job.bucket == nil {
+				continue
+			}
+			lock, unlock := exclusive.RLock, exclusive.RUnlock
+			if serialSideEffects && buckets.HasSideEffects(job.name) {
+				lock, unlock = exclusive.Lock, exclusive.Unlock
+			}
+			// acquire in the order of the jobs so that an exclusive bucket
+			// is not starved by the following ones
+			lock()
+			slots <- struct{}{}
+			go func(job *bucketJob) {
+				defer close(job.done)
+				defer unlock()
+				defer func() { <-slots }()
+				start := time.Now()
+				pluginConfig.Logger.Info("running bucket", "bucket", canonicalName(job.name))
+				job.results, job.err = runBucket(ctx, job.bucket, job.finished)
+				log := pluginConfig.Logger.With("bucket", canonicalName(job.name), "duration", time.Since(start).Round(time.Millisecond))
+				if job.err != nil {
+					log.Info("bucket failed", "error", job.err)
+				} else {
+					log.Info("bucket completed")
+				}
+			}(job)
+		}
+	}()
+}
+
+// newLogger creates the logger of the buckets on the standard error, so that it
+// does not mix with the results, warnings are always displayed, then info and
+// debug messages with the verbosity.
+func newLogger(verbosity int) *slog.Logger {
+	level := slog.LevelWarn
+	switch {
+	case verbosity >= 2:
+		level = slog.LevelDebug
+	case verbosity == 1:
+		level = slog.LevelInfo
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// runBucket runs the bucket until ctx is done, buckets that do not watch the
+// context are abandoned to their fate when it happens. finished is closed when
+// Run returns, or right away if it was not called.
+func runBucket(ctx context.Context, b bucket.Interface, finished chan struct{}) (bucket.Results, error) {
+	if err := ctx.Err(); err != nil {
+		close(finished)
+		return bucket.Results{}, err
+	}
+	type outcome struct {
+		results bucket.Results
+		err     error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer close(finished)
+		results, err := b.Run(ctx)
+		done <- outcome{results, err}
+	}()
+	select {
+	case o := <-done:
+		// buckets that stopped because of the deadline report their own error
+		if o.err != nil && ctx.Err() != nil {
+			return bucket.Results{}, ctx.Err()
+		}
+		return o.results, o.err
+	case <-ctx.Done():
+		return bucket.Results{}, ctx.Err()
+	}
+}
+
+// waitSideEffects waits at most grace for the Run methods of the buckets with
+// side effects to return and returns the names of the ones still running. The
+// buckets only reading are not waited for.
+func waitSideEffects(jobs []*bucketJob, grace time.Duration) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	var running []string
+	for _, job := range jobs {
+		if !job.sideEffects || job.finished == nil {
+			continue
+		}
+		select {
+		case <-job.finished:
+		case <-ctx.Done():
+			running = append(running, job.name)
+		}
+	}
+	return running
+}
+
 func removeDuplicates(list []string) []string {
 	set := make(map[string]bool)
 	out := []string{}
@@ -143,49 +370,162 @@ func removeDuplicates(list []string) []string {
 	return out
 }
 
-// loadContext loads the kubernetes client and the current namespace into the
-// config
-func loadContext(config *bucket.Config) error {
-	// if we load the client, we will also need the current namespace
-	if namespace != "" {
-		config.Namespace = namespace
+// indexEntry describes the file of a bucket in the index of the output
+// directory.
+type indexEntry struct {
+	Bucket   string `json:"bucket"`
+	File     string `json:"file"`
+	Severity string `json:"severity,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// report prints the results of a bucket, or its error if runErr is not nil,
+// and writes them to their file in the output directory if set.
+func report(name string, results bucket.Results, runErr error) (indexEntry, error) {
+	var p string
+	var err error
+	if runErr != nil {
+		p, err = formatError(runErr, name)
+	} else {
+		p, err = formatResults(results, bucket.ResultsOpts{OutputWidth: outputWidth})
+	}
+	if err != nil {
+		return indexEntry{}, err
+	}
+	fmt.Print(p)
+
+	if outputDir == "" {
+		return indexEntry{}, nil
+	}
+	entry := indexEntry{
+		Bucket: canonicalName(name),
+		File:   bucketFileName(name),
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
 	} else {
-		ns, err := automaticontext.CurrentNamespace()
-		if err != nil {
-			return err
+		entry.Severity = results.Severity().String()
+	}
+	return entry, os.WriteFile(filepath.Join(outputDir, entry.File), []byte(p), 0o644)
+}
+
+// prepareOutputDir creates the output directory and checks that the files of
+// the buckets would not overwrite previous results, unless forced.
+func prepareOutputDir(names []string) error {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create the output directory: %w", err)
+	}
+	if force {
+		return nil
+	}
+	files := []string{indexFileName()}
+	for _, name := range names {
+		files = append(files, bucketFileName(name))
+	}
+	for _, f := range files {
+		path := filepath.Join(outputDir, f)
+		if _, err := os.Lstat(path); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", path)
 		}
-		config.Namespace = ns
 	}
+	return nil
+}
 
-	cf, err := automaticontext.Client(kubeconfig)
+// writeIndex writes the list of the files of the buckets with their severity
+// or error, in JSON for the human output.
+func writeIndex(entries []indexEntry) error {
+	index := struct {
+		Date    string       `json:"date"`
+		Buckets []indexEntry `json:"buckets"`
+	}{
+		Date:    time.Now().Format(time.RFC3339),
+		Buckets: entries,
+	}
+	var p []byte
+	var err error
+	if output == outputYAML {
+		p, err = yaml.Marshal(index)
+	} else {
+		p, err = json.MarshalIndent(index, "", "  ")
+	}
 	if err != nil {
 		return err
 	}
-	config.Client = cf
+	return os.WriteFile(filepath.Join(outputDir, indexFileName()), p, 0o644)
+}
 
-	return nil
+func canonicalName(name string) string {
+	if canonical, ok := buckets.ResolveAlias(name); ok {
+		return canonical
+	}
+	return name
 }
 
-func init() {
-	rootCmd.AddCommand(digCmd)
+func bucketFileName(name string) string {
+	switch output {
+	case outputJSON:
+		return canonicalName(name) + ".json"
+	case outputYAML:
+		return canonicalName(name) + ".yaml"
+	default:
+		return canonicalName(name) + ".txt"
+	}
+}
 
-	// kubeconfig flags
-	if home := homedir.HomeDir(); home != "" {
-		path := filepath.Join(home, ".kube", "config")
-		_, err := os.Stat(path)
-		if !os.IsNotExist(err) {
-			digCmd.Flags().StringVar(&kubeconfig, "kubeconfig", path, "(optional) absolute path to the kubeconfig file")
-		}
-	} else {
-		digCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+func indexFileName() string {
+	if output == outputYAML {
+		return "index.yaml"
 	}
+	return "index.json"
+}
+
+// loadContext loads the kubernetes client and the current namespace into the
+// config, once for all the buckets requiring them
+func loadContext(config *bucket.Config) error {
+	if config.Client != nil {
+		return nil
+	}
+	return config.LoadClient()
+}
+
+func init() {
+	rootCmd.AddCommand(digCmd)
 
+	// kubeconfig flags, the in-cluster configuration is used when running in
+	// a pod unless they are set
+	digCmd.Flags().StringVar(&pluginConfig.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file, used instead of the in-cluster configuration. (default to the KUBECONFIG env var or ~/.kube/config out of a cluster)")
+	digCmd.Flags().StringVar(&pluginConfig.KubeContext, "context", "", "Name of the kubeconfig context to use instead of the current one, used instead of the in-cluster configuration.")
 	digCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Kubernetes namespace to use. (default to the namespace in the context)")
 	digCmd.Flags().BoolVarP(&sideEffects, "side-effects", "s", false, "Enable all buckets that might have side effect on environment.")
+	digCmd.Flags().StringVarP(&outputDir, "output-dir", "", "", "Write the results of each bucket to its own file in this directory, with an index of the files.")
+	digCmd.Flags().BoolVarP(&force, "force", "", false, "Overwrite the existing files in the output directory.")
+	digCmd.Flags().CountVarP(&verbosity, "verbose", "v", "Log what the buckets are doing on the standard error, repeat to log debug messages.")
+	digCmd.Flags().IntVarP(&parallel, "parallel", "", 0, "Maximum number of buckets running at the same time. (default to the number of usable CPUs)")
+	digCmd.Flags().BoolVarP(&serialSideEffects, "serial-side-effects", "", false, "Run the buckets with side effects alone, after the previous buckets completed.")
+	digCmd.Flags().DurationVarP(&scanTimeout, "timeout", "", 0, "Deadline of the whole scan, the buckets that did not complete before it are reported as timed out. (default no deadline)")
+	digCmd.Flags().StringToStringVarP(&pluginOptions, "option", "", nil, "Generic option of a bucket in the bucket.key=value format, can be repeated, the list command shows the options of each bucket.")
+	digCmd.Flags().StringVarP(&failOn, "fail-on", "", "", "Exit with a non-zero code if a bucket reports findings of at least this severity or fails. One of: low|medium|high|critical.")
 
 	digCmd.Flags().BoolVarP(&pluginConfig.Color, "color", "c", false, "Enable color in output. (default true if output is human)")
 	digCmd.Flags().BoolVarP(&pluginConfig.AdmForce, "admission-force", "", false, "Force creation of pods to scan admission even without cleaning rights. (this flag is specific to the admission bucket)")
 	digCmd.Flags().BoolVarP(&pluginConfig.AdmCreate, "admission-create", "", false, "Actually create pods to scan admission instead of using server dry run. (this flag is specific to the admission bucket)")
+	digCmd.Flags().DurationVarP(&pluginConfig.Timeout, "admission-timeout", "", 30*time.Second, "Deadline of the API calls, applied to each namespace with --admission-all-namespaces, cleanup is still attempted after it expires. (this flag is specific to the admission bucket)")
+	digCmd.Flags().IntVarP(&pluginConfig.AdmRetries, "admission-retries", "", 3, "Number of retries of the pod creations failing on throttling or timeouts. (this flag is specific to the admission bucket)")
+	digCmd.Flags().DurationVarP(&pluginConfig.AdmRetryBackoff, "admission-retry-backoff", "", 200*time.Millisecond, "Delay before the first retry of a pod creation, doubled at each retry. (this flag is specific to the admission bucket)")
+	digCmd.Flags().BoolVarP(&pluginConfig.AdmAllNamespaces, "admission-all-namespaces", "", false, "Scan all the namespaces that can be listed with dry run instead of the current one. (this flag is specific to the admission bucket)")
+	digCmd.Flags().StringSliceVarP(&pluginConfig.AdmManifests, "admission-manifests", "", nil, "List of paths to pod manifests to scan in addition to the built-in pods. (this flag is specific to the admission bucket)")
+	digCmd.Flags().BoolVarP(&pluginConfig.AuthorizationMatrix, "authorization-matrix", "", false, "Check a matrix of common verbs and resources with access reviews instead of listing the rules. (this flag is specific to the authorization bucket)")
+	digCmd.Flags().StringSliceVarP(&pluginConfig.InternalUIs, "internal-uis", "", nil, "List of internal UIs to probe in the name=host:port format instead of the default ones. (this flag is specific to the internalui bucket)")
+	digCmd.Flags().BoolVarP(&pluginConfig.ServicesProbe, "services-probe", "", false, "Try to connect to the discovered services to check if they are reachable, requires the side effects flag. (this flag is specific to the services bucket)")
+	digCmd.Flags().BoolVarP(&pluginConfig.SyscallsAdaptive, "syscalls-adaptive", "", false, "Calibrate the syscalls scan concurrency and timeout to the environment. (this flag is specific to the syscalls bucket)")
+	digCmd.Flags().DurationVarP(&pluginConfig.SyscallScanTimeout, "syscalls-timeout", "", syscalls.DefaultScanTimeout, "Time after which a syscall that did not return is considered allowed, ignored in adaptive mode. (this flag is specific to the syscalls bucket)")
+	digCmd.Flags().BoolVarP(&pluginConfig.SyscallsDeep, "syscalls-deep", "", false, "Probe curated syscalls with several arguments to detect the ones only conditionally allowed. (this flag is specific to the syscalls bucket)")
+	digCmd.Flags().StringVarP(&pluginConfig.SyscallsSeccompProfile, "syscalls-seccomp-profile", "", "", "Write a seccomp profile allowing the syscalls detected as allowed to this path. (this flag is specific to the syscalls bucket)")
+	digCmd.Flags().StringVarP(&pluginConfig.SyscallsSave, "syscalls-save", "", "", "Save the syscalls scan to this path to compare it later. (this flag is specific to the syscalls bucket)")
+	digCmd.Flags().StringVarP(&pluginConfig.SyscallsCompare, "syscalls-compare", "", "", "Compare the syscalls scan with a scan saved at this path and report the newly blocked, newly allowed and unchanged syscalls. (this flag is specific to the syscalls bucket)")
+	digCmd.Flags().BoolVarP(&pluginConfig.TokenClaims, "token-claims", "", false, "Display all the decoded claims of the token, like the subject and the bound pod. (this flag is specific to the token bucket)")
+	digCmd.Flags().BoolVarP(&pluginConfig.TokenReview, "token-review", "", false, "Authenticate with the tokens to the API server to report their user and groups, requires the side effects flag. (this flag is specific to the token bucket)")
+	digCmd.Flags().StringSliceVarP(&pluginConfig.TokenPaths, "token-paths", "", nil, "List of files and directories to search for tokens instead of the default ones. (this flag is specific to the token bucket)")
 	// this one is retrieved from the root cmd because applicable to many cmds
 	pluginConfig.OutputWidth = outputWidth
 }
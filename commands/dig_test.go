@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+// cleanupBucket ignores the deadline while cleaning up, like the admission
+// bucket deleting its pods with its own context.
+type cleanupBucket struct {
+	cleanup time.Duration
+	cleaned chan struct{}
+}
+
+func (b cleanupBucket) Run(ctx context.Context) (bucket.Results, error) {
+	<-ctx.Done()
+	time.Sleep(b.cleanup)
+	close(b.cleaned)
+	return bucket.Results{}, ctx.Err()
+}
+
+// stuckBucket never returns until release is closed.
+type stuckBucket struct {
+	release chan struct{}
+}
+
+func (b stuckBucket) Run(_ context.Context) (bucket.Results, error) {
+	<-b.release
+	return bucket.Results{}, nil
+}
+
+func newJob(name string, b bucket.Interface, sideEffects bool) *bucketJob {
+	return &bucketJob{name: name, bucket: b, sideEffects: sideEffects, done: make(chan struct{}), finished: make(chan struct{})}
+}
+
+func TestRunBucketCleanupAfterDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+	cleaner := cleanupBucket{cleanup: 100 * time.Millisecond, cleaned: make(chan struct{})}
+	jobs := []*bucketJob{
+		newJob("admission", cleaner, true),
+		// a read only bucket stuck forever must not delay the exit
+		newJob("reader", stuckBucket{release: release}, false),
+	}
+	for _, job := range jobs {
+		if _, err := runBucket(ctx, job.bucket, job.finished); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("runBucket(%s) error = %v, want %v", job.name, err, context.DeadlineExceeded)
+		}
+	}
+
+	if running := waitSideEffects(jobs, 5*time.Second); len(running) != 0 {
+		t.Errorf("waitSideEffects() running = %v, want none", running)
+	}
+	select {
+	case <-cleaner.cleaned:
+	default:
+		t.Error("the cleanup of the bucket did not complete before waitSideEffects returned")
+	}
+}
+
+func TestWaitSideEffectsGracePeriod(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	job := newJob("stuck", stuckBucket{release: release}, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := runBucket(ctx, job.bucket, job.finished); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("runBucket() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	running := waitSideEffects([]*bucketJob{job}, 20*time.Millisecond)
+	if len(running) != 1 || running[0] != "stuck" {
+		t.Errorf("waitSideEffects() running = %v, want [stuck]", running)
+	}
+}
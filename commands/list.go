@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"sort"
+
 	"github.com/quarkslab/kdigger/pkg/bucket"
 	"github.com/spf13/cobra"
 )
@@ -14,21 +16,27 @@ var listCmd = &cobra.Command{
 and descriptions. You can pass specific buckets as arguments to have their information.`,
 	RunE: func(_ *cobra.Command, args []string) error {
 
-		var bucketList []string
+		var bucketList []bucket.Bucket
 		if len(args) == 0 {
-			bucketList = buckets.Registered()
+			bucketList = buckets.List()
 		} else {
-			bucketList = args
+			for _, name := range args {
+				if b, found := buckets.Lookup(name); found {
+					bucketList = append(bucketList, b)
+				}
+			}
 		}
 
 		// leveraging bucket results to print even if it's not a plugin
 		res := bucket.NewResults("List")
-		res.SetHeaders([]string{"name", "aliases", "description", "sideEffects", "requireClient"})
-		for _, name := range bucketList {
-			fullname, found := buckets.ResolveAlias(name)
-			if found {
-				res.AddContent([]interface{}{fullname, buckets.Aliases(name), buckets.Describe(name), buckets.HasSideEffects(name), buckets.RequiresClient(name)})
+		res.SetHeaders([]string{"name", "aliases", "description", "sideEffects", "requireClient", "options"})
+		for _, b := range bucketList {
+			options := make([]string, 0, len(b.Options))
+			for key := range b.Options {
+				options = append(options, key)
 			}
+			sort.Strings(options)
+			res.AddContent([]interface{}{b.Name, b.Aliases, b.Description, b.SideEffects, b.RequireClient, options})
 		}
 
 		showName := false
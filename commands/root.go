@@ -9,26 +9,69 @@ import (
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
 	"github.com/quarkslab/kdigger/pkg/plugins/admission"
+	"github.com/quarkslab/kdigger/pkg/plugins/anonymous"
 	"github.com/quarkslab/kdigger/pkg/plugins/apiresources"
+	"github.com/quarkslab/kdigger/pkg/plugins/audit"
 	"github.com/quarkslab/kdigger/pkg/plugins/authorization"
+	"github.com/quarkslab/kdigger/pkg/plugins/binfmt"
 	"github.com/quarkslab/kdigger/pkg/plugins/capabilities"
+	"github.com/quarkslab/kdigger/pkg/plugins/capdrop"
+	"github.com/quarkslab/kdigger/pkg/plugins/capinning"
+	"github.com/quarkslab/kdigger/pkg/plugins/cgrouplimits"
 	"github.com/quarkslab/kdigger/pkg/plugins/cgroups"
+	"github.com/quarkslab/kdigger/pkg/plugins/cloudcreds"
 	"github.com/quarkslab/kdigger/pkg/plugins/cloudmetadata"
+	"github.com/quarkslab/kdigger/pkg/plugins/cmdlinecreds"
 	"github.com/quarkslab/kdigger/pkg/plugins/containerdetect"
+	"github.com/quarkslab/kdigger/pkg/plugins/controlplane"
 	"github.com/quarkslab/kdigger/pkg/plugins/devices"
+	"github.com/quarkslab/kdigger/pkg/plugins/dnsconfig"
 	"github.com/quarkslab/kdigger/pkg/plugins/environment"
+	"github.com/quarkslab/kdigger/pkg/plugins/ephemeral"
+	"github.com/quarkslab/kdigger/pkg/plugins/exposure"
+	"github.com/quarkslab/kdigger/pkg/plugins/filesystem"
+	"github.com/quarkslab/kdigger/pkg/plugins/firmware"
+	"github.com/quarkslab/kdigger/pkg/plugins/hostaliases"
+	"github.com/quarkslab/kdigger/pkg/plugins/hostnetwork"
+	"github.com/quarkslab/kdigger/pkg/plugins/hostpath"
+	"github.com/quarkslab/kdigger/pkg/plugins/hostroot"
+	"github.com/quarkslab/kdigger/pkg/plugins/hostslices"
+	"github.com/quarkslab/kdigger/pkg/plugins/imageuser"
+	"github.com/quarkslab/kdigger/pkg/plugins/impersonate"
+	"github.com/quarkslab/kdigger/pkg/plugins/injectedsecrets"
+	"github.com/quarkslab/kdigger/pkg/plugins/internalui"
+	"github.com/quarkslab/kdigger/pkg/plugins/iolimits"
+	"github.com/quarkslab/kdigger/pkg/plugins/kernelcmdline"
+	"github.com/quarkslab/kdigger/pkg/plugins/kubeletlogs"
+	"github.com/quarkslab/kdigger/pkg/plugins/localcomponents"
 	"github.com/quarkslab/kdigger/pkg/plugins/mount"
+	"github.com/quarkslab/kdigger/pkg/plugins/network"
 	"github.com/quarkslab/kdigger/pkg/plugins/node"
+	"github.com/quarkslab/kdigger/pkg/plugins/oom"
+	"github.com/quarkslab/kdigger/pkg/plugins/pathdirs"
 	"github.com/quarkslab/kdigger/pkg/plugins/pidnamespace"
+	"github.com/quarkslab/kdigger/pkg/plugins/policies"
+	"github.com/quarkslab/kdigger/pkg/plugins/probes"
 	"github.com/quarkslab/kdigger/pkg/plugins/processes"
+	"github.com/quarkslab/kdigger/pkg/plugins/procroot"
+	"github.com/quarkslab/kdigger/pkg/plugins/releaseagent"
 	"github.com/quarkslab/kdigger/pkg/plugins/runtime"
+	"github.com/quarkslab/kdigger/pkg/plugins/saprivileges"
+	"github.com/quarkslab/kdigger/pkg/plugins/scheduling"
+	"github.com/quarkslab/kdigger/pkg/plugins/seccompops"
 	"github.com/quarkslab/kdigger/pkg/plugins/services"
+	"github.com/quarkslab/kdigger/pkg/plugins/settime"
+	"github.com/quarkslab/kdigger/pkg/plugins/sharedvolumes"
 	"github.com/quarkslab/kdigger/pkg/plugins/syscalls"
+	"github.com/quarkslab/kdigger/pkg/plugins/sysctls"
 	"github.com/quarkslab/kdigger/pkg/plugins/token"
+	"github.com/quarkslab/kdigger/pkg/plugins/tokenmount"
 	"github.com/quarkslab/kdigger/pkg/plugins/userid"
 	"github.com/quarkslab/kdigger/pkg/plugins/usernamespace"
 	"github.com/quarkslab/kdigger/pkg/plugins/version"
+	"github.com/quarkslab/kdigger/pkg/plugins/webhooks"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
 // buckets stores all the plugins
@@ -40,6 +83,21 @@ var output string
 // var for the output width
 var outputWidth int
 
+// flag to print the registered buckets in JSON instead of the help
+var listBuckets bool
+
+// exit codes of the command, the findings and bucket errors codes are only
+// used with the --fail-on flag and can be combined, 6 meaning both
+const (
+	exitCodeError        = 1
+	exitCodeFindings     = 2
+	exitCodeBucketErrors = 4
+)
+
+// exitCode is the code of a successful execution, it is not 0 when the
+// findings met the --fail-on threshold or buckets failed
+var exitCode int
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "kdigger",
@@ -49,26 +107,34 @@ cluster. For that you can use multiples buckets. Buckets are plugins that can
 scan specific aspects of a cluster or bring expertise to automate the Kubernetes
 pentest process.`,
 	PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
-		if output != outputHuman && output != outputJSON {
-			return fmt.Errorf("output flag must be one of %s|%s, got %q", outputHuman, outputJSON, output)
+		if output != outputHuman && output != outputJSON && output != outputYAML {
+			return fmt.Errorf("output flag must be one of %s|%s|%s, got %q", outputHuman, outputJSON, outputYAML, output)
 		}
 		return nil
 	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if !listBuckets {
+			return cmd.Help()
+		}
+		return printBucketList(buckets.List())
+	},
 }
 
 func init() {
 	cobra.OnInitialize(registerBuckets)
 
-	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputHuman, fmt.Sprintf("Output format. One of: %s|%s.", outputHuman, outputJSON))
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputHuman, fmt.Sprintf("Output format. One of: %s|%s|%s.", outputHuman, outputJSON, outputYAML))
 	rootCmd.PersistentFlags().IntVarP(&outputWidth, "width", "w", 140, fmt.Sprintf("Width for the %s output", outputHuman))
+	rootCmd.Flags().BoolVarP(&listBuckets, "list-buckets", "", false, "Print the registered buckets and their metadata in JSON, for scripts and wrappers.")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitCodeError)
 	}
+	os.Exit(exitCode)
 }
 
 // registerBuckets registers all the modules into the buckets, newly created
@@ -96,32 +162,117 @@ func registerBuckets() {
 	apiresources.Register(buckets)
 	cloudmetadata.Register(buckets)
 	containerdetect.Register(buckets)
+	releaseagent.Register(buckets)
+	webhooks.Register(buckets)
+	oom.Register(buckets)
+	procroot.Register(buckets)
+	iolimits.Register(buckets)
+	controlplane.Register(buckets)
+	cmdlinecreds.Register(buckets)
+	hostslices.Register(buckets)
+	capinning.Register(buckets)
+	hostaliases.Register(buckets)
+	ephemeral.Register(buckets)
+	hostnetwork.Register(buckets)
+	policies.Register(buckets)
+	firmware.Register(buckets)
+	capdrop.Register(buckets)
+	internalui.Register(buckets)
+	imageuser.Register(buckets)
+	impersonate.Register(buckets)
+	cgrouplimits.Register(buckets)
+	hostpath.Register(buckets)
+	anonymous.Register(buckets)
+	pathdirs.Register(buckets)
+	settime.Register(buckets)
+	hostroot.Register(buckets)
+	dnsconfig.Register(buckets)
+	audit.Register(buckets)
+	sharedvolumes.Register(buckets)
+	exposure.Register(buckets)
+	seccompops.Register(buckets)
+	cloudcreds.Register(buckets)
+	binfmt.Register(buckets)
+	scheduling.Register(buckets)
+	kernelcmdline.Register(buckets)
+	injectedsecrets.Register(buckets)
+	kubeletlogs.Register(buckets)
+	probes.Register(buckets)
+	localcomponents.Register(buckets)
+	tokenmount.Register(buckets)
+	saprivileges.Register(buckets)
+	network.Register(buckets)
+	sysctls.Register(buckets)
+	filesystem.Register(buckets)
+}
+
+// printBucketList prints the metadata of the buckets as a JSON array,
+// whatever the output flag, so that its format stays stable for the tools.
+func printBucketList(list []bucket.Bucket) error {
+	for i := range list {
+		// encode the buckets without aliases as empty arrays, not null
+		if list[i].Aliases == nil {
+			list[i].Aliases = []string{}
+		}
+	}
+	b, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(b))
+	return nil
 }
 
 // printResults prints results with the output format selected by the flags
 func printResults(r bucket.Results, opts bucket.ResultsOpts) error {
+	p, err := formatResults(r, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Print(p)
+	return nil
+}
+
+// formatResults serializes results with the output format selected by the
+// flags, as they are printed
+func formatResults(r bucket.Results, opts bucket.ResultsOpts) (string, error) {
 	switch output {
 	case outputHuman:
-		fmt.Print(r.Human(opts))
+		return r.Human(opts), nil
 	case outputJSON:
 		p, err := r.JSON(opts)
 		if err != nil {
-			return err
+			return "", err
 		}
-		fmt.Println(p)
+		return p + "\n", nil
+	case outputYAML:
+		// every bucket is a YAML document of the stream
+		p, err := r.YAML(opts)
+		if err != nil {
+			return "", err
+		}
+		return "---\n" + p, nil
 	default:
-		return errors.New("internal error, check on output flag must have been done in PersistentPreRunE")
+		return "", errors.New("internal error, check on output flag must have been done in PersistentPreRunE")
 	}
-	return nil
 }
 
 // printError prints error, maybe it would make more sense to return a Results
 // struct that can contains the error directly?
 func printError(err error, name string) error {
+	p, err := formatError(err, name)
+	if err != nil {
+		return err
+	}
+	fmt.Print(p)
+	return nil
+}
+
+// formatError serializes the error of a bucket like formatResults
+func formatError(err error, name string) (string, error) {
 	switch output {
 	case outputHuman:
-		fmt.Printf("### %s ###\n", strings.ToUpper(name))
-		fmt.Printf("Error: %s\n", err.Error())
+		return fmt.Sprintf("### %s ###\nError: %s\n", strings.ToUpper(name), err.Error()), nil
 	case outputJSON:
 		jsonErr := struct {
 			Bucket string `json:"bucket"`
@@ -133,11 +284,19 @@ func printError(err error, name string) error {
 
 		bJSONErr, err := json.Marshal(jsonErr)
 		if err != nil {
-			return err
+			return "", err
 		}
-		fmt.Println(string(bJSONErr))
+		return string(bJSONErr) + "\n", nil
+	case outputYAML:
+		bYAMLErr, err := yaml.Marshal(map[string]string{
+			"bucket": name,
+			"error":  err.Error(),
+		})
+		if err != nil {
+			return "", err
+		}
+		return "---\n" + string(bYAMLErr), nil
 	default:
-		return errors.New("internal error, check on output flag must have been done in PersistentPreRunE")
+		return "", errors.New("internal error, check on output flag must have been done in PersistentPreRunE")
 	}
-	return nil
 }
@@ -1,10 +1,16 @@
 package bucket
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
 )
@@ -20,7 +26,9 @@ func (e UnknownBucketError) Error() string {
 }
 
 type Runnable interface {
-	Run() (Results, error)
+	// Run executes the bucket, long running buckets should stop early and
+	// return when ctx is done
+	Run(ctx context.Context) (Results, error)
 }
 
 type Interface interface {
@@ -29,15 +37,20 @@ type Interface interface {
 
 type Factory func(config Config) (Interface, error)
 
+// Bucket is the metadata of a registered plugin, it is encoded in JSON without
+// its factory for the tools discovering the buckets.
 type Bucket struct {
-	Name        string
-	Description string
-	Aliases     []string
-	Factory     Factory
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Aliases     []string `json:"aliases"`
+	Factory     Factory  `json:"-"`
 	// has side-effects on its environment or is just readonly
-	SideEffects bool
+	SideEffects bool `json:"sideEffects"`
 	// requires a client to communicate with the API server
-	RequireClient bool
+	RequireClient bool `json:"requireClient"`
+	// documents the generic options consumed by the bucket, from their keys
+	// to their descriptions, see Options
+	Options map[string]string `json:"options,omitempty"`
 }
 
 type Buckets struct {
@@ -48,16 +61,77 @@ type Buckets struct {
 }
 
 type Config struct {
-	Client      kubernetes.Interface
-	Namespace   string
+	Client    kubernetes.Interface
+	Namespace string
+	// Kubeconfig and KubeContext select the cluster of the client populated
+	// by LoadClient, they are empty for the defaults
+	Kubeconfig  string
+	KubeContext string
 	Color       bool
 	OutputWidth int
+	// Logger receives the progress and debug messages of the buckets, use
+	// Log to retrieve it since it can be nil
+	Logger *slog.Logger
+	// Options are the generic options of the plugins, for the knobs that do
+	// not deserve a dedicated field
+	Options Options
 	// This options is specific to the admission plugin, is it to force creation
 	// even if we can't cleanup the mess with delete
 	AdmForce bool
 	// This options is specific to the admission plugin, is it to actually create
 	// pod instead of use the dry run
 	AdmCreate bool
+	// This options is specific to the admission plugin, it is the paths of
+	// pod manifests to scan in addition to the built-in pods
+	AdmManifests []string
+	// This options is specific to the admission plugin, it scans all the
+	// namespaces that can be listed with dry run instead of the current one
+	AdmAllNamespaces bool
+	// This options is specific to the admission plugin, it is the number of
+	// retries of the pod creations failing on transient errors, like the
+	// throttling, and the delay before the first one, doubled at each retry
+	AdmRetries      int
+	AdmRetryBackoff time.Duration
+	// This options is specific to the admission plugin for now, it is the
+	// deadline of the API calls, zero meaning no deadline
+	Timeout time.Duration
+	// This options is specific to the authorization plugin, it checks a
+	// matrix of common verbs and resources with access reviews instead of
+	// listing the rules, useful when the authorizer can't list them
+	AuthorizationMatrix bool
+	// This options is specific to the internalui plugin, it overrides the
+	// default list of UIs to probe, in the "name=host:port" format
+	InternalUIs []string
+	// This options is specific to the services plugin, it tries to connect
+	// to the discovered services, which generates network traffic
+	ServicesProbe bool
+	// This options is specific to the syscalls plugin, it calibrates the scan
+	// concurrency and timeout to the environment
+	SyscallsAdaptive bool
+	// This options is specific to the syscalls plugin, it is the time after
+	// which a syscall that did not return is considered allowed
+	SyscallScanTimeout time.Duration
+	// This options is specific to the syscalls plugin, it probes curated
+	// syscalls with several arguments to detect argument filtering
+	SyscallsDeep bool
+	// This options is specific to the syscalls plugin, it is the path where
+	// a seccomp profile generated from the scan is written
+	SyscallsSeccompProfile string
+	// This options is specific to the syscalls plugin, it is the path where
+	// the scan is saved for a later comparison
+	SyscallsSave string
+	// This options is specific to the syscalls plugin, it is the path of a
+	// saved scan to compare with, only the changed syscalls are reported
+	SyscallsCompare string
+	// This options is specific to the token plugin, it displays all the
+	// decoded claims of the token, like the subject and the bound pod
+	TokenClaims bool
+	// This options is specific to the token plugin, it overrides the
+	// default list of files and directories searched for tokens
+	TokenPaths []string
+	// This options is specific to the token plugin, it authenticates with the
+	// tokens to the API server to check that they are valid
+	TokenReview bool
 }
 
 func NewBuckets() *Buckets {
@@ -68,6 +142,79 @@ func NewConfig() *Config {
 	return &Config{}
 }
 
+// Options carry arbitrary plugin options, the keys are prefixed with the name
+// of the bucket consuming them, like "sysctls.extra", and the plugins parse
+// the values themselves with the typed getters, that return the default value
+// if the key is not set.
+type Options map[string]string
+
+func (o Options) String(key string, def string) string {
+	if v, ok := o[key]; ok {
+		return v
+	}
+	return def
+}
+
+// StringSlice splits the comma separated value, ignoring the empty elements.
+func (o Options) StringSlice(key string, def []string) []string {
+	v, ok := o[key]
+	if !ok {
+		return def
+	}
+	var values []string
+	for _, e := range strings.Split(v, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			values = append(values, e)
+		}
+	}
+	return values
+}
+
+func (o Options) Bool(key string, def bool) (bool, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid option %s: %w", key, err)
+	}
+	return b, nil
+}
+
+func (o Options) Int(key string, def int) (int, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid option %s: %w", key, err)
+	}
+	return i, nil
+}
+
+func (o Options) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := o[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def, fmt.Errorf("invalid option %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// Log returns the logger of the configuration, or a logger discarding the
+// messages if none was set.
+func (c Config) Log() *slog.Logger {
+	if c.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return c.Logger
+}
+
 // Registered enumerates the names of all registered plugins.
 func (bs *Buckets) Registered() []string {
 	bs.lock.RLock()
@@ -112,33 +259,50 @@ func (bs *Buckets) Register(b Bucket) {
 	bs.lock.Lock()
 	defer bs.lock.Unlock()
 
-	// check if the plugin wasn't already registered
-	if bs.registry != nil {
-		_, found := bs.registry[b.Name]
-		if found {
-			panic(fmt.Sprintf("bucket %q was registered twice", b.Name))
-		}
-	} else {
+	if bs.registry == nil {
 		bs.registry = map[string]Bucket{}
 	}
-
-	// register the plugin
-	bs.registry[b.Name] = b
-
-	// register the aliases of the plugin
 	if bs.aliases == nil {
 		bs.aliases = map[string]string{}
 	}
-	// name unicity was already checked
+
+	// check that the name and the aliases do not shadow another plugin before
+	// registering anything, names are also aliases of their plugin
+	if _, found := bs.registry[b.Name]; found {
+		panic(fmt.Sprintf("bucket %q was registered twice", b.Name))
+	}
+	for _, alias := range append([]string{b.Name}, b.Aliases...) {
+		if owner, found := bs.aliases[alias]; found {
+			panic(fmt.Sprintf("bucket registration: aliases %q conflict for bucket %q, already used by bucket %q", alias, b.Name, owner))
+		}
+	}
+
+	// register the plugin and its aliases
+	bs.registry[b.Name] = b
 	bs.aliases[b.Name] = b.Name
 	for _, alias := range b.Aliases {
-		if _, found := bs.aliases[alias]; found {
-			panic(fmt.Sprintf("bucket registration: aliases %q conflict for bucket %q", alias, b.Name))
-		}
 		bs.aliases[alias] = b.Name
 	}
 }
 
+// ValidateOptions checks that every option is consumed by a registered
+// bucket, to catch the typos that would silently be ignored.
+func (bs *Buckets) ValidateOptions(options Options) error {
+	bs.lock.RLock()
+	defer bs.lock.RUnlock()
+	for key := range options {
+		name, _, _ := strings.Cut(key, ".")
+		b, found := bs.registry[name]
+		if !found {
+			return fmt.Errorf("unknown option %q, options keys must be prefixed with the name of a bucket", key)
+		}
+		if _, found := b.Options[key]; !found {
+			return fmt.Errorf("unknown option %q for bucket %q", key, name)
+		}
+	}
+	return nil
+}
+
 func (bs *Buckets) ResolveAlias(alias string) (string, bool) {
 	e, found := bs.findEntryFromAlias(alias)
 	return e.Name, found
@@ -189,6 +353,47 @@ func (bs *Buckets) InitBucket(name string, config Config) (Interface, error) {
 	return bucket, nil
 }
 
+// List returns the metadata of all registered plugins sorted by name, it
+// does not instantiate them.
+func (bs *Buckets) List() []Bucket {
+	bs.lock.RLock()
+	defer bs.lock.RUnlock()
+	list := make([]Bucket, 0, len(bs.registry))
+	for _, b := range bs.registry {
+		list = append(list, copyBucket(b))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Lookup returns the metadata of the plugin designated by its name or one of
+// its aliases, it returns false if no plugin matches.
+func (bs *Buckets) Lookup(name string) (Bucket, bool) {
+	e, found := bs.findEntryFromAlias(name)
+	if !found {
+		return Bucket{}, false
+	}
+	return copyBucket(e), true
+}
+
+// copyBucket prevents the callers from modifying the aliases and the options
+// of the registry.
+func copyBucket(b Bucket) Bucket {
+	if b.Aliases != nil {
+		b.Aliases = append([]string{}, b.Aliases...)
+	}
+	if b.Options != nil {
+		options := make(map[string]string, len(b.Options))
+		for k, v := range b.Options {
+			options[k] = v
+		}
+		b.Options = options
+	}
+	return b
+}
+
 func (bs *Buckets) Describe(name string) string {
 	e, found := bs.findEntryFromAlias(name)
 	if !found {
@@ -221,11 +426,53 @@ func (bs *Buckets) RequiresClient(name string) bool {
 	return e.RequireClient
 }
 
+// Severity ranks how critical the findings of a bucket are, the zero value
+// means that the bucket did not qualify its results.
+type Severity uint8
+
+const (
+	SeverityNone Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityNone:
+		return ""
+	case SeverityLow:
+		return "LOW"
+	case SeverityMedium:
+		return "MEDIUM"
+	case SeverityHigh:
+		return "HIGH"
+	case SeverityCritical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ParseSeverity returns the severity matching its name, case insensitive.
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical} {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return SeverityNone, fmt.Errorf("unknown severity %q, must be one of low|medium|high|critical", name)
+}
+
 type Results struct {
 	bucketName string
 	headers    []string
 	data       [][]interface{}
 	comments   []string
+	severity   Severity
+	// related tables attached by the bucket, rendered after the main one
+	subResults []Results
 }
 
 // ResultsOpts uses pointers to have a default nil value that will be evaluated
@@ -256,3 +503,92 @@ func (r *Results) AddComment(comment string) {
 func (r *Results) AddContent(content []interface{}) {
 	r.data = append(r.data, content)
 }
+
+// ErrorHeader is the conventional name of the last column of the results
+// filled with AddContentWithError.
+const ErrorHeader = "error"
+
+// AddContentWithError adds a row followed by the message of err in the error
+// column, the cell is left empty if err is nil so that every row has the same
+// width.
+func (r *Results) AddContentWithError(content []interface{}, err error) {
+	row := make([]interface{}, 0, len(content)+1)
+	row = append(row, content...)
+	if err != nil {
+		row = append(row, err.Error())
+	} else {
+		row = append(row, "")
+	}
+	r.AddContent(row)
+}
+
+// RaiseSeverity sets the severity of the results to s only if it is higher
+// than the current one, so that buckets can raise it for every finding.
+func (r *Results) RaiseSeverity(s Severity) {
+	if s > r.severity {
+		r.severity = s
+	}
+}
+
+// AddSubResults attaches a related table to the results, for buckets that
+// produce more than one, sub results are named with their own name.
+func (r *Results) AddSubResults(sub Results) {
+	r.subResults = append(r.subResults, sub)
+}
+
+func (r Results) SubResults() []Results {
+	return r.subResults
+}
+
+// Merge appends the content, the comments and the sub results of other to the
+// results, to combine the runs of a bucket in several namespaces or over time.
+// The comments already present are not repeated and the highest severity is
+// kept. The headers must be identical unless one of the results is empty.
+func (r *Results) Merge(other Results) error {
+	switch {
+	case len(other.headers) == 0 && len(other.data) == 0:
+	case len(r.headers) == 0 && len(r.data) == 0:
+		r.headers = other.headers
+	case !equalHeaders(r.headers, other.headers):
+		return fmt.Errorf("cannot merge results of %q with headers %v into results with headers %v", other.bucketName, other.headers, r.headers)
+	}
+	r.data = append(r.data, other.data...)
+	for _, comment := range other.comments {
+		if !containsComment(r.comments, comment) {
+			r.comments = append(r.comments, comment)
+		}
+	}
+	r.RaiseSeverity(other.severity)
+	r.subResults = append(r.subResults, other.subResults...)
+	return nil
+}
+
+func equalHeaders(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsComment(comments []string, comment string) bool {
+	for _, c := range comments {
+		if c == comment {
+			return true
+		}
+	}
+	return false
+}
+
+// Severity returns the highest severity of the results and their sub results.
+func (r Results) Severity() Severity {
+	s := r.severity
+	for _, sub := range r.subResults {
+		s = max(s, sub.Severity())
+	}
+	return s
+}
@@ -0,0 +1,210 @@
+package bucket
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testBucket(name string, aliases ...string) Bucket {
+	return Bucket{
+		Name:        name,
+		Description: "test bucket",
+		Aliases:     aliases,
+		Factory: func(_ Config) (Interface, error) {
+			return nil, nil
+		},
+	}
+}
+
+// registerPanic returns the panic message of the registration, empty if it
+// did not panic.
+func registerPanic(bs *Buckets, b Bucket) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = r.(string)
+		}
+	}()
+	bs.Register(b)
+	return ""
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		conflict Bucket
+	}{
+		{"same name", testBucket("capabilities")},
+		{"overlapping alias", testBucket("capdrop", "drop", "cap")},
+		{"alias shadowing a name", testBucket("other", "capabilities")},
+		{"name shadowing an alias", testBucket("caps")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := NewBuckets()
+			bs.Register(testBucket("capabilities", "caps", "cap"))
+
+			if msg := registerPanic(bs, tt.conflict); msg == "" || !strings.Contains(msg, "registered twice") && !strings.Contains(msg, "conflict") {
+				t.Fatalf("Register(%v) did not panic with a conflict, got %q", tt.conflict.Name, msg)
+			}
+
+			// the conflicting bucket must not be partially registered
+			if got := bs.Registered(); len(got) != 1 || got[0] != "capabilities" {
+				t.Errorf("Registered() = %v, want only capabilities", got)
+			}
+			for _, alias := range []string{"capabilities", "caps", "cap"} {
+				if name, _ := bs.ResolveAlias(alias); name != "capabilities" {
+					t.Errorf("ResolveAlias(%q) = %q, want capabilities", alias, name)
+				}
+			}
+			if _, found := bs.ResolveAlias("drop"); found {
+				t.Error("alias drop of the conflicting bucket was registered")
+			}
+		})
+	}
+}
+
+func TestListAndLookup(t *testing.T) {
+	bs := NewBuckets()
+	bs.Register(testBucket("token", "tokens", "tk"))
+	capabilities := testBucket("capabilities", "caps")
+	capabilities.SideEffects = true
+	bs.Register(capabilities)
+
+	list := bs.List()
+	if len(list) != 2 || list[0].Name != "capabilities" || list[1].Name != "token" {
+		t.Fatalf("List() = %v, want capabilities and token", list)
+	}
+	if !list[0].SideEffects {
+		t.Error("List() lost the side effects of capabilities")
+	}
+
+	b, found := bs.Lookup("tk")
+	if !found || b.Name != "token" {
+		t.Fatalf("Lookup(tk) = %q, %v, want token", b.Name, found)
+	}
+	// the returned metadata is a copy of the registry
+	b.Aliases[0] = "modified"
+	if b, _ := bs.Lookup("token"); b.Aliases[0] != "tokens" {
+		t.Errorf("Lookup returned the aliases of the registry, got %v", b.Aliases)
+	}
+
+	if _, found := bs.Lookup("unknown"); found {
+		t.Error("Lookup(unknown) found a bucket")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	options := Options{
+		"token.paths":   "/a, ,/b,",
+		"token.review":  "true",
+		"token.retries": "three",
+		"token.timeout": "2s",
+	}
+
+	if v := options.String("token.missing", "default"); v != "default" {
+		t.Errorf("String(missing) = %q, want the default", v)
+	}
+	if v := options.StringSlice("token.paths", nil); len(v) != 2 || v[0] != "/a" || v[1] != "/b" {
+		t.Errorf("StringSlice(paths) = %q, want [/a /b]", v)
+	}
+	if v, err := options.Bool("token.review", false); err != nil || !v {
+		t.Errorf("Bool(review) = %v, %v, want true", v, err)
+	}
+	if v, err := options.Duration("token.timeout", time.Second); err != nil || v != 2*time.Second {
+		t.Errorf("Duration(timeout) = %v, %v, want 2s", v, err)
+	}
+	if v, err := options.Int("token.retries", 1); err == nil || v != 1 {
+		t.Errorf("Int(retries) = %v, %v, want the default and an error", v, err)
+	}
+	// a nil map is the configuration without options
+	if v, err := Options(nil).Int("token.retries", 1); err != nil || v != 1 {
+		t.Errorf("Int(retries) on nil options = %v, %v, want the default", v, err)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	bs := NewBuckets()
+	token := testBucket("token")
+	token.Options = map[string]string{"token.review": "Review the tokens."}
+	bs.Register(token)
+
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"documented", Options{"token.review": "true"}, false},
+		{"unknown key", Options{"token.reviews": "true"}, true},
+		{"unknown bucket", Options{"admission.timeout": "1s"}, true},
+		{"no prefix", Options{"review": "true"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bs.ValidateOptions(tt.options); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBucketJSON(t *testing.T) {
+	bs := NewBuckets()
+	b := testBucket("token", "tokens", "tk")
+	b.RequireClient = true
+	bs.Register(b)
+
+	raw, err := json.Marshal(bs.List())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"name":"token","description":"test bucket","aliases":["tokens","tk"],"sideEffects":false,"requireClient":true}]`
+	if string(raw) != want {
+		t.Errorf("json.Marshal(List()) = %s, want %s", raw, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	merged := NewResults("admission")
+	// the results of a failed run only carry a comment
+	failed := NewResults("admission")
+	failed.AddComment("The namespace is forbidden.")
+	if err := merged.Merge(*failed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, namespace := range []string{"default", "kube-system"} {
+		run := NewResults("admission")
+		run.SetHeaders([]string{"namespace", "pod", "success"})
+		run.AddContent([]interface{}{namespace, "privileged", namespace == "kube-system"})
+		run.AddComment("Pods were created with dry run.")
+		if namespace == "kube-system" {
+			run.RaiseSeverity(SeverityHigh)
+		}
+		if err := merged.Merge(*run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(merged.headers) != 3 || len(merged.data) != 2 {
+		t.Errorf("Merge() headers = %v, data = %v, want the 3 headers and 2 rows", merged.headers, merged.data)
+	}
+	if len(merged.comments) != 2 {
+		t.Errorf("Merge() comments = %q, want the 2 distinct comments", merged.comments)
+	}
+	if merged.Severity() != SeverityHigh {
+		t.Errorf("Merge() severity = %s, want %s", merged.Severity(), SeverityHigh)
+	}
+
+	other := NewResults("admission")
+	other.SetHeaders([]string{"pod", "success"})
+	other.AddContent([]interface{}{"privileged", true})
+	if err := merged.Merge(*other); err == nil {
+		t.Error("Merge() with different headers should fail")
+	}
+	if len(merged.data) != 2 {
+		t.Errorf("failed Merge() modified the results, got %d rows", len(merged.data))
+	}
+}
@@ -0,0 +1,89 @@
+package bucket
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	// import all auth clients
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+)
+
+// serviceAccountNamespace is the namespace of the pod mounted with its token.
+const serviceAccountNamespace = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// NewClient builds a client and returns the namespace of its context. In a
+// pod, the in-cluster configuration of the service account comes first,
+// unless a kubeconfig path or a context is explicitly given. Otherwise, the
+// kubeconfig is loaded from the given path, or from the KUBECONFIG env var and
+// ~/.kube/config, with the given context or its current one.
+func NewClient(kubeconfig string, kubeContext string) (kubernetes.Interface, string, error) {
+	if kubeconfig == "" && kubeContext == "" {
+		config, err := rest.InClusterConfig()
+		switch {
+		case err == nil:
+			client, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				return nil, "", err
+			}
+			namespace := "default"
+			if b, err := os.ReadFile(serviceAccountNamespace); err == nil {
+				namespace = strings.TrimSpace(string(b))
+			}
+			return client, namespace, nil
+		case !errors.Is(err, rest.ErrNotInCluster):
+			return nil, "", fmt.Errorf("failed to load the in-cluster configuration: %w", err)
+		}
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+		CurrentContext: kubeContext,
+	})
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, namespace, nil
+}
+
+// OptionalClient returns the client of the configuration, loading it with
+// LoadClient if it is missing since the runner only loads the client of the
+// buckets requiring one. The buckets that only use it to refine their results
+// should treat the error as a soft one.
+func (c *Config) OptionalClient() (kubernetes.Interface, error) {
+	if c.Client == nil {
+		if err := c.LoadClient(); err != nil {
+			return nil, err
+		}
+	}
+	return c.Client, nil
+}
+
+// LoadClient populates the client of the configuration with NewClient, from
+// its kubeconfig and context, and its namespace if it is not already set.
+func (c *Config) LoadClient() error {
+	client, namespace, err := NewClient(c.Kubeconfig, c.KubeContext)
+	if err != nil {
+		return err
+	}
+	c.Client = client
+	if c.Namespace == "" {
+		c.Namespace = namespace
+	}
+	return nil
+}
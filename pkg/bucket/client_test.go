@@ -0,0 +1,87 @@
+package bucket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: laptop
+clusters:
+- name: laptop
+  cluster:
+    server: https://laptop.example:6443
+- name: remote
+  cluster:
+    server: https://remote.example:6443
+users:
+- name: user
+  user:
+    token: token
+contexts:
+- name: laptop
+  context:
+    cluster: laptop
+    user: user
+- name: remote
+  context:
+    cluster: remote
+    user: user
+    namespace: scanned
+`
+
+func TestNewClient(t *testing.T) {
+	// out of a cluster, the in-cluster configuration must not be found
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		kubeContext   string
+		wantHost      string
+		wantNamespace string
+	}{
+		{"current context", "", "laptop.example:6443", "default"},
+		{"explicit context", "remote", "remote.example:6443", "scanned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, namespace, err := NewClient(path, tt.kubeContext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if host := client.Discovery().RESTClient().Get().URL().Host; host != tt.wantHost {
+				t.Errorf("NewClient() host = %s, want %s", host, tt.wantHost)
+			}
+			if namespace != tt.wantNamespace {
+				t.Errorf("NewClient() namespace = %s, want %s", namespace, tt.wantNamespace)
+			}
+		})
+	}
+
+	if _, _, err := NewClient(path, "unknown"); err == nil {
+		t.Error("NewClient() with an unknown context should fail")
+	}
+}
+
+func TestLoadClientNamespace(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the namespace already set takes precedence over the one of the context
+	config := Config{Kubeconfig: path, KubeContext: "remote", Namespace: "flag"}
+	if err := config.LoadClient(); err != nil {
+		t.Fatal(err)
+	}
+	if config.Client == nil || config.Namespace != "flag" {
+		t.Errorf("LoadClient() client = %v, namespace = %s, want a client and flag", config.Client, config.Namespace)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
+	"sigs.k8s.io/yaml"
 )
 
 // checkWidthsCoherence checks if headers and data are both sets, that the
@@ -53,6 +54,7 @@ func (r Results) formatTable(outputWidth int) string {
 		{Number: 4, AlignHeader: text.AlignCenter, WidthMaxEnforcer: text.WrapSoft, WidthMax: maxWidth},
 		{Number: 5, AlignHeader: text.AlignCenter, WidthMaxEnforcer: text.WrapSoft, WidthMax: maxWidth},
 		{Number: 6, AlignHeader: text.AlignCenter, WidthMaxEnforcer: text.WrapSoft, WidthMax: maxWidth},
+		{Number: 7, AlignHeader: text.AlignCenter, WidthMaxEnforcer: text.WrapSoft, WidthMax: maxWidth},
 	})
 	headers := make(table.Row, len(r.headers))
 	for i := range r.headers {
@@ -69,10 +71,19 @@ func (r Results) formatTable(outputWidth int) string {
 }
 
 func (r Results) Human(opts ResultsOpts) string {
+	return r.human(opts, "###")
+}
+
+// human formats the results with their name surrounded by marker, sub results
+// use a shorter marker than their parent.
+func (r Results) human(opts ResultsOpts, marker string) string {
 	var output strings.Builder
 
 	if opts.ShowName == nil || *opts.ShowName {
-		output.WriteString(fmt.Sprintf("### %s ###\n", strings.ToUpper(r.bucketName)))
+		output.WriteString(fmt.Sprintf("%s %s %s\n", marker, strings.ToUpper(r.bucketName), marker))
+	}
+	if severity := r.Severity(); severity != SeverityNone {
+		output.WriteString(fmt.Sprintf("Severity: %s\n", severity))
 	}
 	if len(r.comments) != 0 {
 		if opts.ShowComments == nil || *opts.ShowComments {
@@ -91,19 +102,30 @@ func (r Results) Human(opts ResultsOpts) string {
 			output.WriteString("\n")
 		}
 	}
+	subMarker := marker
+	if len(subMarker) > 1 {
+		subMarker = subMarker[1:]
+	}
+	for _, sub := range r.subResults {
+		output.WriteString(sub.human(opts, subMarker))
+	}
 	return output.String()
 }
 
-func (r Results) JSON(opts ResultsOpts) (string, error) {
+// structured returns the results in the shape of the JSON and YAML outputs,
+// rows are maps of the headers to the cells.
+func (r Results) structured(opts ResultsOpts) (interface{}, error) {
 	if !r.checkWidthsCoherence() {
-		return "", fmt.Errorf("cannot output JSON for bucket %q, inconsistence between width of headers and data", r.bucketName)
+		return nil, fmt.Errorf("cannot output bucket %q, inconsistence between width of headers and data", r.bucketName)
 	}
 
 	type jsonOutput struct {
-		Bucket   string                   `json:"bucket"`
-		Comments []string                 `json:"comments,omitempty"`
-		Results  []map[string]interface{} `json:"results,omitempty"`
-		Result   map[string]interface{}   `json:"result,omitempty"`
+		Bucket     string                   `json:"bucket"`
+		Severity   string                   `json:"severity,omitempty"`
+		Comments   []string                 `json:"comments,omitempty"`
+		Results    []map[string]interface{} `json:"results,omitempty"`
+		Result     map[string]interface{}   `json:"result,omitempty"`
+		SubResults []interface{}            `json:"subResults,omitempty"`
 	}
 
 	dataMap := make([]map[string]interface{}, 0)
@@ -118,39 +140,85 @@ func (r Results) JSON(opts ResultsOpts) (string, error) {
 		}
 	}
 
-	var b []byte
-	var err error
-	// if hide name and comments, directly output an array of results
-	if (opts.ShowName != nil && !*opts.ShowName) && (opts.ShowComments != nil && !*opts.ShowComments) {
+	// if hide name and comments, directly output an array of results, unless
+	// sub results would be lost
+	if (opts.ShowName != nil && !*opts.ShowName) && (opts.ShowComments != nil && !*opts.ShowComments) && len(r.subResults) == 0 {
 		if len(dataMap) == 1 {
 			// flatten it, the result is not iterable
-			b, err = json.Marshal(dataMap[0])
-		} else {
-			b, err = json.Marshal(dataMap)
+			return dataMap[0], nil
 		}
-	} else {
-		o := jsonOutput{}
-		// TODO maybe add omitempty
-		if opts.ShowName == nil || *opts.ShowName {
-			o.Bucket = r.bucketName
-		}
-		if opts.ShowComments == nil || *opts.ShowComments {
-			o.Comments = r.comments
+		return dataMap, nil
+	}
+
+	o := jsonOutput{}
+	// TODO maybe add omitempty
+	if opts.ShowName == nil || *opts.ShowName {
+		o.Bucket = r.bucketName
+	}
+	o.Severity = r.Severity().String()
+	if opts.ShowComments == nil || *opts.ShowComments {
+		o.Comments = r.comments
+	}
+	if opts.ShowData == nil || *opts.ShowData {
+		if len(dataMap) == 1 {
+			// flatten it, the result is not iterable
+			o.Result = dataMap[0]
+		} else {
+			o.Results = dataMap
 		}
-		if opts.ShowData == nil || *opts.ShowData {
-			if len(dataMap) == 1 {
-				// flatten it, the result is not iterable
-				o.Result = dataMap[0]
-			} else {
-				o.Results = dataMap
-			}
+	}
+	for _, sub := range r.subResults {
+		subOutput, err := sub.structured(opts)
+		if err != nil {
+			return nil, err
 		}
+		o.SubResults = append(o.SubResults, subOutput)
+	}
+	return o, nil
+}
 
-		b, err = json.Marshal(o)
+func (r Results) JSON(opts ResultsOpts) (string, error) {
+	o, err := r.structured(opts)
+	if err != nil {
+		return "", err
 	}
+	b, err := json.Marshal(o)
 	if err != nil {
 		panic(err)
 	}
+	return string(b), nil
+}
 
+// YAML is the YAML equivalent of JSON, cells keep their structure, slices
+// become sequences.
+func (r Results) YAML(opts ResultsOpts) (string, error) {
+	o, err := r.structured(opts)
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(o)
+	if err != nil {
+		return "", err
+	}
 	return string(b), nil
 }
+
+// MarshalJSON emits the raw results, with the headers and the content rows
+// as separate fields, for consumers of the Results struct itself.
+func (r Results) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Bucket     string          `json:"bucket"`
+		Severity   string          `json:"severity"`
+		Comments   []string        `json:"comments"`
+		Headers    []string        `json:"headers"`
+		Content    [][]interface{} `json:"content"`
+		SubResults []Results       `json:"subResults,omitempty"`
+	}{
+		Bucket:     r.bucketName,
+		Severity:   r.Severity().String(),
+		Comments:   r.comments,
+		Headers:    r.headers,
+		Content:    r.data,
+		SubResults: r.subResults,
+	})
+}
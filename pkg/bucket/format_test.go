@@ -0,0 +1,120 @@
+package bucket
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSubResults(t *testing.T) {
+	res := NewResults("api")
+	res.SetHeaders([]string{"version"})
+	res.AddContent([]interface{}{"v1.30.0"})
+
+	sub := NewResults("secrets")
+	sub.SetHeaders([]string{"namespace", "name"})
+	sub.AddContent([]interface{}{"default", "db"})
+	sub.AddContent([]interface{}{"default", "tls"})
+	sub.RaiseSeverity(SeverityHigh)
+	res.AddSubResults(*sub)
+
+	if got := res.Severity(); got != SeverityHigh {
+		t.Errorf("Severity() = %s, want the HIGH severity of the sub results", got)
+	}
+
+	human := res.Human(ResultsOpts{OutputWidth: 80})
+	if !strings.Contains(human, "### API ###") || !strings.Contains(human, "## SECRETS ##") {
+		t.Errorf("Human() does not name both tables:\n%s", human)
+	}
+	if strings.Index(human, "v1.30.0") > strings.Index(human, "tls") {
+		t.Errorf("Human() does not render the sub results after the main table:\n%s", human)
+	}
+
+	out, err := res.JSON(ResultsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Bucket     string `json:"bucket"`
+		SubResults []struct {
+			Bucket   string              `json:"bucket"`
+			Severity string              `json:"severity"`
+			Results  []map[string]string `json:"results"`
+		} `json:"subResults"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.SubResults) != 1 || decoded.SubResults[0].Bucket != "secrets" || len(decoded.SubResults[0].Results) != 2 {
+		t.Errorf("JSON() = %s, want the secrets sub results", out)
+	}
+
+	// a single table keeps the same output
+	single, err := sub.JSON(ResultsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(single, "subResults") {
+		t.Errorf("JSON() = %s, want no sub results", single)
+	}
+}
+
+func TestSubResultsHiddenNameAndComments(t *testing.T) {
+	res := NewResults("api")
+	res.SetHeaders([]string{"version"})
+	res.AddContent([]interface{}{"v1.30.0"})
+	res.AddComment("hidden")
+	sub := NewResults("secrets")
+	sub.SetHeaders([]string{"namespace", "name"})
+	sub.AddContent([]interface{}{"default", "db"})
+	sub.RaiseSeverity(SeverityHigh)
+	res.AddSubResults(*sub)
+
+	hide := false
+	out, err := res.JSON(ResultsOpts{ShowName: &hide, ShowComments: &hide})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Severity   string                   `json:"severity"`
+		Comments   []string                 `json:"comments"`
+		Result     map[string]string        `json:"result"`
+		SubResults []map[string]interface{} `json:"subResults"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Result["version"] != "v1.30.0" || len(decoded.SubResults) != 1 || decoded.SubResults[0]["name"] != "db" {
+		t.Errorf("JSON() = %s, want the main and the sub results", out)
+	}
+	if decoded.Severity != SeverityHigh.String() || len(decoded.Comments) != 0 {
+		t.Errorf("JSON() = %s, want the severity without the comments", out)
+	}
+}
+
+func TestAddContentWithError(t *testing.T) {
+	res := NewResults("probe")
+	res.SetHeaders([]string{"endpoint", "reachable", ErrorHeader})
+	content := []interface{}{"10.0.0.1:443", false}
+	res.AddContentWithError(content, errors.New("connection refused"))
+	res.AddContentWithError([]interface{}{"10.0.0.2:443", true}, nil)
+
+	if len(content) != 2 {
+		t.Errorf("AddContentWithError modified the content, got %v", content)
+	}
+
+	out, err := res.JSON(ResultsOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Results) != 2 || decoded.Results[0][ErrorHeader] != "connection refused" || decoded.Results[1][ErrorHeader] != "" {
+		t.Errorf("JSON() = %s, want the error messages in the error column", out)
+	}
+}
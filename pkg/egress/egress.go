@@ -0,0 +1,47 @@
+package egress
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DefaultTimeout is short on purpose, endpoints are supposed to be in the
+// cluster or on the node and should answer quickly.
+const DefaultTimeout = 300 * time.Millisecond
+
+// Probe is the result of a reachability test against an endpoint.
+type Probe struct {
+	Address   string
+	Reachable bool
+	Error     error
+}
+
+// TCP tries to open a TCP connection to the address in the "host:port" form
+// and closes it right away.
+func TCP(ctx context.Context, address string, timeout time.Duration) Probe {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return Probe{Address: address, Reachable: false, Error: err}
+	}
+	conn.Close()
+	return Probe{Address: address, Reachable: true}
+}
+
+// TCPAll probes all the addresses concurrently and returns the probes in the
+// same order as the addresses.
+func TCPAll(ctx context.Context, addresses []string, timeout time.Duration) []Probe {
+	probes := make([]Probe, len(addresses))
+	done := make(chan struct{}, len(addresses))
+	for i, address := range addresses {
+		go func(i int, address string) {
+			probes[i] = TCP(ctx, address, timeout)
+			done <- struct{}{}
+		}(i, address)
+	}
+	for range addresses {
+		<-done
+	}
+	return probes
+}
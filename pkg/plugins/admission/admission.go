@@ -3,14 +3,21 @@ package admission
 import (
 	"context"
 	"errors"
-	"reflect"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
 const (
@@ -20,25 +27,114 @@ const (
 
 var bucketAliases = []string{"admissions", "adm"}
 
-var currentNamespace string
+// cleanupWorkers is the maximum number of concurrent pod deletions.
+const cleanupWorkers = 4
+
+// podFactoryTimeout bounds the creation of each pod, the results of the
+// factories still running collectGracePeriod later are reported as errors.
+var podFactoryTimeout = 10 * time.Second
+
+const collectGracePeriod = time.Second
+
+// webhookRegexp extracts the name of the webhook from its denial message.
+var webhookRegexp = regexp.MustCompile(`admission webhook "([^"]+)" denied`)
+
+// blockingMechanisms identify the mechanism that rejected the pod from the
+// error message, the first matching one wins.
+var blockingMechanisms = []struct {
+	name     string
+	patterns []string
+}{
+	{"PodSecurity", []string{"violates PodSecurity"}},
+	{"PodSecurityPolicy", []string{"PodSecurityPolicy", "pod security policy"}},
+	{"Gatekeeper", []string{"gatekeeper.sh"}},
+	{"Kyverno", []string{"kyverno"}},
+	{"ValidatingAdmissionPolicy", []string{"ValidatingAdmissionPolicy"}},
+	{"ResourceQuota", []string{"exceeded quota"}},
+	{"LimitRange", []string{"LimitRange", "per Container is"}},
+	{"RBAC", []string{"cannot create resource"}},
+}
 
 // Bucket implements Bucket
 type Bucket struct {
-	client kubernetes.Interface
+	client    kubernetes.Interface
+	namespace string
 
-	podFactoryChain []podFactory
+	podFactoryChain []PodFactory
 	podsToClean     []*v1.Pod
 	cleaningLock    *sync.Mutex
 
 	config bucket.Config
 }
 
+// modes of the creation of the pods in the results
+const (
+	modeDryRun = "dryRun"
+	modeCreate = "create"
+)
+
+// outcomes of the creation of the pods, only a denied pod was rejected by the
+// admission chain, the others failed before or around it and did not test it
+const (
+	outcomeAllowed   = "allowed"
+	outcomeDenied    = "denied"
+	outcomeForbidden = "forbidden"
+	outcomeTimeout   = "timeout"
+	outcomeError     = "error"
+)
+
 type admissionResult struct {
 	pod     string
+	mode    string
 	success bool
 	err     error
 }
 
+// blockedBy returns the likely mechanism that rejected the pod, "unknown"
+// if the error is not recognized.
+func blockedBy(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	for _, m := range blockingMechanisms {
+		for _, p := range m.patterns {
+			if strings.Contains(msg, p) {
+				return m.name
+			}
+		}
+	}
+	if match := webhookRegexp.FindStringSubmatch(msg); match != nil {
+		return "webhook " + match[1]
+	}
+	return "unknown"
+}
+
+// outcome classifies the error of the creation of a pod, to tell the pods
+// blocked by a policy from the ones that could not even be tested.
+func outcome(err error) string {
+	if err == nil {
+		return outcomeAllowed
+	}
+	if errors.Is(err, context.DeadlineExceeded) || kerrors.IsTimeout(err) || kerrors.IsServerTimeout(err) {
+		return outcomeTimeout
+	}
+	// the authorization happens before the admission, RBAC denials mean that
+	// the admission chain was not reached
+	if kerrors.IsUnauthorized(err) || (kerrors.IsForbidden(err) && blockedBy(err) == "RBAC") {
+		return outcomeForbidden
+	}
+	// webhooks that could not be called reject the pods with failurePolicy
+	// Fail, but it says nothing about their policy
+	if strings.Contains(err.Error(), "failed calling webhook") {
+		return outcomeError
+	}
+	if kerrors.IsForbidden(err) || webhookRegexp.MatchString(err.Error()) {
+		return outcomeDenied
+	}
+	return outcomeError
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
@@ -53,74 +149,225 @@ func Register(b *bucket.Buckets) {
 }
 
 // Run runs the admission test.
-func (a *Bucket) Run() (bucket.Results, error) {
+func (a *Bucket) Run(parent context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
-	if a.config.AdmCreate && !a.config.AdmForce && !a.CanIDelete() {
+	ctx, cancel := a.newContext(parent)
+	defer cancel()
+	if a.config.AdmCreate && !a.config.AdmAllNamespaces && !a.config.AdmForce && !a.CanIDelete(ctx) {
 		return *res, errors.New("cannot delete pod, will not be able to clean the scan artifacts, force creation with --admission-force")
 	}
-	a.initialize()
-	c := make(chan admissionResult, len(a.podFactoryChain))
-
-	for _, f := range a.podFactoryChain {
-		go func(a *Bucket, f podFactory, c chan admissionResult) {
-			err := a.use(f)
-			if err != nil {
-				// if kerrors.IsForbidden(err) {
-				c <- admissionResult{
-					pod:     reflect.TypeOf(f).Name(),
-					success: false,
-					err:     err,
-				}
-				return
-				// }
-			}
-			c <- admissionResult{
-				pod:     reflect.TypeOf(f).Name(),
-				success: true,
-				err:     nil,
-			}
-		}(a, f, c)
+	if err := a.initialize(); err != nil {
+		return *res, err
+	}
+	var untested int
+	if a.config.AdmAllNamespaces {
+		// the deadline applies to each namespace, a single one for the whole
+		// scan would skip the last namespaces of large clusters
+		var err error
+		untested, err = a.scanAllNamespaces(parent, res)
+		if err != nil {
+			return *res, err
+		}
+	} else {
+		results := a.scan(ctx, a.namespace)
+		untested = countUntested(results)
+		*res = report("", results)
+	}
+	if untested > 0 {
+		res.AddComment(fmt.Sprintf("%d of the pods could not be tested against the admission chain, see their outcome for the reason.", untested))
 	}
 
-	var results []admissionResult
-	for i := 0; i < cap(c); i++ {
-		results = append(results, <-c)
+	// the scan context might have expired or been cancelled, cleanup gets its
+	// own deadline to still delete the pods that were created
+	cleanupCtx, cleanupCancel := a.newContext(context.WithoutCancel(parent))
+	defer cleanupCancel()
+	err := a.Cleanup(cleanupCtx)
+	if a.config.AdmForce {
+		err = nil
+	}
+	switch {
+	case parent.Err() != nil:
+		err = errors.Join(fmt.Errorf("admission scan interrupted: %w", parent.Err()), err)
+	case !a.config.AdmAllNamespaces && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = errors.Join(fmt.Errorf("admission scan timed out after %s", a.config.Timeout), err)
 	}
+	return *res, err
+}
 
-	res.SetHeaders([]string{"pod", "success", "error"})
+// scanAllNamespaces scans the namespaces that can be listed one after the
+// other and merges their results into res, prefixed with the namespace. The
+// namespaces where the creation of all the pods is forbidden are skipped.
+// Each namespace gets its own deadline derived from parent.
+func (a *Bucket) scanAllNamespaces(parent context.Context, res *bucket.Results) (int, error) {
+	listCtx, cancel := a.newContext(parent)
+	namespaces, err := a.client.CoreV1().Namespaces().List(listCtx, metav1.ListOptions{})
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the namespaces: %w", err)
+	}
+	var untested int
+	var forbidden, timedOut []string
+	for _, namespace := range namespaces.Items {
+		if parent.Err() != nil {
+			break
+		}
+		ctx, cancel := a.newContext(parent)
+		results := a.scan(ctx, namespace.Name)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			timedOut = append(timedOut, namespace.Name)
+		}
+		cancel()
+		if countOutcome(results, outcomeForbidden) == len(results) {
+			forbidden = append(forbidden, namespace.Name)
+			continue
+		}
+		untested += countUntested(results)
+		if err := res.Merge(report(namespace.Name, results)); err != nil {
+			return untested, err
+		}
+	}
+	if len(forbidden) > 0 {
+		res.AddComment(fmt.Sprintf("The creation of pods is forbidden in some namespaces, they were skipped: %v.", forbidden))
+	}
+	if len(timedOut) > 0 {
+		res.AddComment(fmt.Sprintf("The scan timed out after %s in some namespaces, their results are incomplete: %v.", a.config.Timeout, timedOut))
+	}
+	return untested, nil
+}
+
+// report converts the results of a scan to bucket results, with a first
+// namespace column if namespace is not empty.
+func report(namespace string, results []admissionResult) bucket.Results {
+	res := bucket.NewResults(bucketName)
+	headers := []string{"pod", "mode", "success", "outcome", "blockedBy", bucket.ErrorHeader}
+	if namespace != "" {
+		headers = append([]string{"namespace"}, headers...)
+	}
+	res.SetHeaders(headers)
+	for _, r := range results {
+		row := []interface{}{r.pod, r.mode, r.success, outcome(r.err), blockedBy(r.err)}
+		if namespace != "" {
+			row = append([]interface{}{namespace}, row...)
+		}
+		res.AddContentWithError(row, r.err)
+	}
+	return *res
+}
+
+// countUntested returns the number of pods that did not reach the admission
+// chain.
+func countUntested(results []admissionResult) int {
+	return len(results) - countOutcome(results, outcomeAllowed) - countOutcome(results, outcomeDenied)
+}
+
+func countOutcome(results []admissionResult, o string) int {
+	var n int
 	for _, r := range results {
-		if r.err != nil {
-			res.AddContent([]interface{}{r.pod, r.success, r.err})
-		} else {
-			res.AddContent([]interface{}{r.pod, r.success, ""})
+		if outcome(r.err) == o {
+			n++
 		}
 	}
+	return n
+}
 
-	err := a.Cleanup()
-	if a.config.AdmForce {
-		return *res, nil
+// scan creates the pods of every factory concurrently in namespace and
+// returns their results in the order of the chain.
+func (a *Bucket) scan(ctx context.Context, namespace string) []admissionResult {
+	type indexedResult struct {
+		index  int
+		result admissionResult
 	}
-	return *res, err
+	c := make(chan indexedResult, len(a.podFactoryChain))
+	var wg sync.WaitGroup
+	creates := false
+	for i, f := range a.podFactoryChain {
+		creates = creates || a.mode(f) == modeCreate
+		wg.Add(1)
+		go func(i int, f PodFactory) {
+			defer wg.Done()
+			factoryCtx, cancel := context.WithTimeout(ctx, podFactoryTimeout)
+			defer cancel()
+			err := a.use(factoryCtx, f, namespace)
+			c <- indexedResult{i, admissionResult{pod: f.Name(), mode: a.mode(f), success: err == nil, err: err}}
+		}(i, f)
+	}
+
+	// a call ignoring its context must not hang the whole bucket, the
+	// collection gives up on the factories that did not report in time, the
+	// channel is buffered so that their goroutines can still exit later
+	results := make([]admissionResult, len(a.podFactoryChain))
+	reported := make([]bool, len(a.podFactoryChain))
+	deadline := time.NewTimer(podFactoryTimeout + collectGracePeriod)
+	defer deadline.Stop()
+collect:
+	for range a.podFactoryChain {
+		select {
+		case r := <-c:
+			results[r.index] = r.result
+			reported[r.index] = true
+		case <-deadline.C:
+			break collect
+		}
+	}
+	for i, f := range a.podFactoryChain {
+		if !reported[i] {
+			results[i] = admissionResult{
+				pod:  f.Name(),
+				mode: a.mode(f),
+				err:  fmt.Errorf("pod creation did not return after %s: %w", podFactoryTimeout, context.DeadlineExceeded),
+			}
+		}
+	}
+	// a pod really created after the collection gave up must still be
+	// registered for the cleanup, so the scan waits for the factories
+	if creates {
+		wg.Wait()
+	}
+	return results
+}
+
+// newContext derives a context with the configured timeout from parent,
+// without additional deadline if the timeout is not set.
+func (a Bucket) newContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if a.config.Timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, a.config.Timeout)
+}
+
+// mode returns how the pod of the factory is created, the factories opting
+// out of the real creation and the scans of all the namespaces always use dry
+// run.
+func (a *Bucket) mode(f PodFactory) string {
+	if d, ok := f.(DryRunOnly); a.config.AdmCreate && !a.config.AdmAllNamespaces && (!ok || !d.DryRunOnly()) {
+		return modeCreate
+	}
+	return modeDryRun
 }
 
-func (a *Bucket) use(f podFactory) error {
-	pod := f.NewPod()
+func (a *Bucket) use(ctx context.Context, f PodFactory, namespace string) error {
+	pod := f.NewPod(namespace)
+	create := a.mode(f) == modeCreate
 
 	// activate server dry run by default
 	var createOptions metav1.CreateOptions
-	if !a.config.AdmCreate {
+	if !create {
 		createOptions = metav1.CreateOptions{
 			DryRun: []string{"All"},
 		}
 	}
 
-	pod, err := a.client.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, createOptions)
+	log := a.config.Log().With("factory", f.Name(), "namespace", pod.Namespace, "dryRun", !create)
+	log.Debug("creating pod")
+	pod, err := a.create(ctx, pod, createOptions, log)
 	if err != nil {
+		log.Debug("pod creation failed", "error", err)
 		return err
 	}
+	log.Debug("pod created", "pod", pod.Name)
 
 	// clean only if we actually created the pod
-	if a.config.AdmCreate {
+	if create {
 		a.cleaningLock.Lock()
 		a.podsToClean = append(a.podsToClean, pod)
 		a.cleaningLock.Unlock()
@@ -128,53 +375,131 @@ func (a *Bucket) use(f podFactory) error {
 	return nil
 }
 
-// initialize initiliazes the pod factory chain to use during the scan.
-func (a *Bucket) initialize() {
-	a.podFactoryChain = []podFactory{
+// create creates the pod, retrying with an exponential backoff on the
+// transient errors since the admission decision was not reached for them. The
+// delay suggested by the API server is honored if it is longer.
+func (a *Bucket) create(ctx context.Context, pod *v1.Pod, options metav1.CreateOptions, log *slog.Logger) (*v1.Pod, error) {
+	backoff := a.config.AdmRetryBackoff
+	for attempt := 1; ; attempt++ {
+		created, err := a.client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, options)
+		if err == nil || attempt > a.config.AdmRetries || !retryable(err) {
+			return created, err
+		}
+		delay := backoff
+		if seconds, ok := kerrors.SuggestsClientDelay(err); ok {
+			delay = max(delay, time.Duration(seconds)*time.Second)
+		}
+		log.Debug("retrying pod creation", "attempt", attempt, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// retryable returns true for the errors of an overloaded or slow API server,
+// never for the admission denials.
+func retryable(err error) bool {
+	return kerrors.IsTooManyRequests(err) || kerrors.IsServerTimeout(err) || kerrors.IsTimeout(err) || kerrors.IsServiceUnavailable(err)
+}
+
+// initialize initiliazes the pod factory chain to use during the scan, with
+// the registered factories and the pods of the user supplied manifests after
+// the built-in ones.
+func (a *Bucket) initialize() error {
+	a.podFactoryChain = []PodFactory{
 		privilegedPod{},
 		hostPathPod{},
 		hostPIDPod{},
 		hostNetworkPod{},
+		hostIPCPod{},
 		runAsRootPod{},
 		privilegeEscalationPod{},
+		addCapabilitiesPod{},
 	}
-}
 
-func (a Bucket) CanIDelete() bool {
-	err := a.client.CoreV1().Pods(currentNamespace).Delete(context.TODO(), "delete-test", metav1.DeleteOptions{})
-	return !kerrors.IsForbidden(err)
-}
+	registryLock.Lock()
+	a.podFactoryChain = append(a.podFactoryChain, registeredFactories...)
+	registryLock.Unlock()
 
-// Cleanup deletes side effects pods that were successfully created during the scan.
-// TODO parallelize maybe?
-func (a Bucket) Cleanup() error {
-	for _, p := range a.podsToClean {
-		err := a.client.CoreV1().Pods(p.Namespace).Delete(context.TODO(), p.Name, metav1.DeleteOptions{})
+	for _, path := range a.config.AdmManifests {
+		f, err := newManifestPod(path)
 		if err != nil {
 			return err
 		}
+		a.podFactoryChain = append(a.podFactoryChain, f)
 	}
 	return nil
 }
 
+func (a Bucket) CanIDelete(ctx context.Context) bool {
+	err := a.client.CoreV1().Pods(a.namespace).Delete(ctx, "delete-test", metav1.DeleteOptions{})
+	return !kerrors.IsForbidden(err)
+}
+
+// Cleanup deletes side effects pods that were successfully created during the
+// scan, every deletion is attempted and the errors are joined.
+func (a Bucket) Cleanup(ctx context.Context) error {
+	a.cleaningLock.Lock()
+	pods := append([]*v1.Pod{}, a.podsToClean...)
+	a.cleaningLock.Unlock()
+
+	log := a.config.Log()
+	if len(pods) > 0 {
+		log.Info("deleting the created pods", "count", len(pods))
+	}
+	jobs := make(chan *v1.Pod)
+	errs := make(chan error, len(pods))
+	var wg sync.WaitGroup
+	for w := 0; w < min(cleanupWorkers, len(pods)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				log.Debug("deleting pod", "pod", p.Name, "namespace", p.Namespace)
+				err := a.client.CoreV1().Pods(p.Namespace).Delete(ctx, p.Name, metav1.DeleteOptions{})
+				if err != nil {
+					errs <- fmt.Errorf("failed to delete pod %s/%s: %w", p.Namespace, p.Name, err)
+				}
+			}
+		}()
+	}
+	for _, p := range pods {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	var cleanupErrs []error
+	for err := range errs {
+		cleanupErrs = append(cleanupErrs, err)
+	}
+	return errors.Join(cleanupErrs...)
+}
+
 // NewAdmissionBucket creates a new admission bucket with the kubernetes client.
 func NewAdmissionBucket(cf bucket.Config) (*Bucket, error) {
 	if cf.Client == nil {
 		return nil, bucket.ErrMissingClient
 	}
-	currentNamespace = cf.Namespace
 	return &Bucket{
 		client:       cf.Client,
+		namespace:    cf.Namespace,
 		cleaningLock: &sync.Mutex{},
 		config:       cf,
 	}, nil
 }
 
-// getGenericPod creates a generic pod.
-func getGenericPod() *v1.Pod {
+// getGenericPod creates a generic pod in namespace.
+func getGenericPod(namespace string) *v1.Pod {
 	return &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace:    currentNamespace,
+			Namespace:    namespace,
 			GenerateName: "admission-bucket-",
 		},
 		Spec: v1.PodSpec{
@@ -188,17 +513,99 @@ func getGenericPod() *v1.Pod {
 	}
 }
 
-// podFactory should be implemented by every particular pod creator to test admission.
-type podFactory interface {
-	NewPod() *v1.Pod
+// PodFactory should be implemented by every particular pod creator to test
+// admission, NewPod receives the namespace of the scan and Name is the label
+// of the pod in the results.
+type PodFactory interface {
+	NewPod(namespace string) *v1.Pod
+	Name() string
+}
+
+// DryRunOnly can be implemented by the factories whose pods must never be
+// actually created, for example because they could really run, they are then
+// scanned with dry run even if the creation was requested.
+type DryRunOnly interface {
+	DryRunOnly() bool
+}
+
+// namedFactory overrides the name of a registered factory.
+type namedFactory struct {
+	PodFactory
+	name string
+}
+
+func (f namedFactory) Name() string {
+	return f.name
+}
+
+var (
+	registeredFactories []PodFactory
+	registryLock        sync.Mutex
+)
+
+// RegisterPodFactory adds a factory to the chain of the next runs, after the
+// built-in ones, so that external code can test its own admission policies.
+func RegisterPodFactory(name string, f PodFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	registeredFactories = append(registeredFactories, namedFactory{PodFactory: f, name: name})
 }
 
-// hostPathPod implements podFactory
+// manifestPod implements PodFactory
+type manifestPod struct {
+	path string
+	pod  *v1.Pod
+}
+
+// newManifestPod parses the pod manifest, in YAML or JSON, at path.
+func newManifestPod(path string) (manifestPod, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return manifestPod{}, err
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(raw, nil, nil)
+	if err != nil {
+		return manifestPod{}, fmt.Errorf("failed to parse the manifest %s: %w", path, err)
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return manifestPod{}, fmt.Errorf("manifest %s is not a pod, found %T", path, obj)
+	}
+	return manifestPod{path: path, pod: pod}, nil
+}
+
+// Name returns the file name of the manifest.
+func (p manifestPod) Name() string {
+	return filepath.Base(p.path)
+}
+
+// DryRunOnly prevents the creation of the pods of the manifests, unlike the
+// built-in pods their image exists and they would run.
+func (p manifestPod) DryRunOnly() bool {
+	return true
+}
+
+// NewPod creates the pod of the manifest, the name is generated to avoid
+// conflicts with the real workload and to be cleaned like the other pods. The
+// namespace of the manifest is replaced by the scanned one.
+func (p manifestPod) NewPod(namespace string) *v1.Pod {
+	pod := p.pod.DeepCopy()
+	pod.Name = ""
+	pod.GenerateName = "admission-bucket-"
+	pod.Namespace = namespace
+	return pod
+}
+
+// hostPathPod implements PodFactory
 type hostPathPod struct{}
 
+func (p hostPathPod) Name() string {
+	return "hostPath"
+}
+
 // NewPod creates a pod with the whole host filesystem mounted.
-func (p hostPathPod) NewPod() *v1.Pod {
-	pod := getGenericPod()
+func (p hostPathPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
 	pod.Spec.Volumes = []v1.Volume{{
 		Name: "rootfs",
 		VolumeSource: v1.VolumeSource{
@@ -210,12 +617,16 @@ func (p hostPathPod) NewPod() *v1.Pod {
 	return pod
 }
 
-// privilegedPod implements podFactory
+// privilegedPod implements PodFactory
 type privilegedPod struct{}
 
+func (p privilegedPod) Name() string {
+	return "privileged"
+}
+
 // NewPod creates a pod with the privileged flag set to true.
-func (p privilegedPod) NewPod() *v1.Pod {
-	pod := getGenericPod()
+func (p privilegedPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
 	privileged := true
 	pod.Spec.Containers[0].SecurityContext = &v1.SecurityContext{
 		Privileged: &privileged,
@@ -223,32 +634,58 @@ func (p privilegedPod) NewPod() *v1.Pod {
 	return pod
 }
 
-// hostNetworkPod implements podFactory
+// hostNetworkPod implements PodFactory
 type hostNetworkPod struct{}
 
+func (p hostNetworkPod) Name() string {
+	return "hostNetwork"
+}
+
 // NewPod creates a pod with host network flag set to true.
-func (p hostNetworkPod) NewPod() *v1.Pod {
-	pod := getGenericPod()
+func (p hostNetworkPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
 	pod.Spec.HostNetwork = true
 	return pod
 }
 
-// hostNetworkPod implements podFactory
+// hostNetworkPod implements PodFactory
 type hostPIDPod struct{}
 
+func (p hostPIDPod) Name() string {
+	return "hostPID"
+}
+
 // NewPod creates a pod with host network flag set to true.
-func (p hostPIDPod) NewPod() *v1.Pod {
-	pod := getGenericPod()
+func (p hostPIDPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
 	pod.Spec.HostPID = true
 	return pod
 }
 
-// runAsRootPod implements podFactory and create a pod
+// hostIPCPod implements PodFactory
+type hostIPCPod struct{}
+
+func (p hostIPCPod) Name() string {
+	return "hostIPC"
+}
+
+// NewPod creates a pod with host IPC flag set to true.
+func (p hostIPCPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
+	pod.Spec.HostIPC = true
+	return pod
+}
+
+// runAsRootPod implements PodFactory and create a pod
 type runAsRootPod struct{}
 
+func (p runAsRootPod) Name() string {
+	return "runAsRoot"
+}
+
 // NewPod creates a container running as root
-func (p runAsRootPod) NewPod() *v1.Pod {
-	pod := getGenericPod()
+func (p runAsRootPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
 	runAsNonRoot := false // this is the default value
 	runAsUser := int64(0)
 	pod.Spec.Containers[0].SecurityContext = &v1.SecurityContext{
@@ -258,15 +695,38 @@ func (p runAsRootPod) NewPod() *v1.Pod {
 	return pod
 }
 
-// privilegeEscalationPod implements podFactory
+// privilegeEscalationPod implements PodFactory
 type privilegeEscalationPod struct{}
 
+func (p privilegeEscalationPod) Name() string {
+	return "privilegeEscalation"
+}
+
 // privilegeEscalationPod creates a container with allowPrivilegeEscalation to true
-func (p privilegeEscalationPod) NewPod() *v1.Pod {
-	pod := getGenericPod()
+func (p privilegeEscalationPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
 	allowPrivilegeEscalation := true
 	pod.Spec.Containers[0].SecurityContext = &v1.SecurityContext{
 		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
 	}
 	return pod
 }
+
+// addCapabilitiesPod implements PodFactory
+type addCapabilitiesPod struct{}
+
+func (p addCapabilitiesPod) Name() string {
+	return "addCapabilities"
+}
+
+// NewPod creates a container adding dangerous capabilities to the default
+// set of the runtime.
+func (p addCapabilitiesPod) NewPod(namespace string) *v1.Pod {
+	pod := getGenericPod(namespace)
+	pod.Spec.Containers[0].SecurityContext = &v1.SecurityContext{
+		Capabilities: &v1.Capabilities{
+			Add: []v1.Capability{"SYS_ADMIN", "NET_ADMIN", "SYS_PTRACE"},
+		},
+	}
+	return pod
+}
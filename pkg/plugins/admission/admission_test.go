@@ -0,0 +1,409 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCleanup(t *testing.T) {
+	names := []string{"pod-a", "pod-b", "pod-c", "pod-d", "pod-e", "pod-f"}
+	var pods []*v1.Pod
+	var objects []runtime.Object
+	for _, name := range names {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		pods = append(pods, pod)
+		objects = append(objects, pod)
+	}
+
+	client := fake.NewSimpleClientset(objects...)
+	// the deletion of pod-b fails, the others must still be deleted
+	client.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.DeleteAction).GetName() == "pod-b" {
+			return true, nil, errors.New("injected error")
+		}
+		return false, nil, nil
+	})
+
+	a := Bucket{
+		client:       client,
+		podsToClean:  pods,
+		cleaningLock: &sync.Mutex{},
+	}
+	err := a.Cleanup(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "pod-b") {
+		t.Fatalf("Cleanup() error = %v, want an error about pod-b", err)
+	}
+
+	for _, name := range names {
+		_, err := client.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		if name == "pod-b" {
+			if err != nil {
+				t.Errorf("pod %s should not have been deleted, got error %v", name, err)
+			}
+			continue
+		}
+		if !kerrors.IsNotFound(err) {
+			t.Errorf("pod %s should have been deleted, got error %v", name, err)
+		}
+	}
+}
+
+type testFactory string
+
+func (f testFactory) NewPod(namespace string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: string(f), Namespace: namespace}}
+}
+
+func (f testFactory) Name() string {
+	return string(f)
+}
+
+func TestScanStuckFactory(t *testing.T) {
+	previous := podFactoryTimeout
+	podFactoryTimeout = 10 * time.Millisecond
+	defer func() { podFactoryTimeout = previous }()
+
+	release := make(chan struct{})
+	defer close(release)
+	client := fake.NewSimpleClientset()
+	// the creation of stuck hangs and ignores its context
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.CreateAction).GetObject().(*v1.Pod).Name == "stuck" {
+			<-release
+		}
+		return false, nil, nil
+	})
+
+	a := Bucket{
+		client:          client,
+		namespace:       "default",
+		podFactoryChain: []PodFactory{testFactory("first"), testFactory("stuck"), testFactory("last")},
+		cleaningLock:    &sync.Mutex{},
+	}
+	// the fake clientset serializes the reactions, the other factories might
+	// be stuck behind the hanging one so only the stuck one is checked
+	results := a.scan(context.Background(), "default")
+	want := []string{"first", "stuck", "last"}
+	if len(results) != len(want) {
+		t.Fatalf("scan() returned %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.pod != want[i] {
+			t.Errorf("result %d is for %s, want %s", i, r.pod, want[i])
+		}
+	}
+	if results[1].success || results[1].err == nil {
+		t.Errorf("stuck factory should report an error, got %+v", results[1])
+	}
+}
+
+func TestRunConcurrentNamespaces(t *testing.T) {
+	namespaces := []string{"namespace-a", "namespace-b"}
+	clients := make([]*fake.Clientset, len(namespaces))
+	var wg sync.WaitGroup
+	for i, namespace := range namespaces {
+		clients[i] = fake.NewSimpleClientset()
+		a, err := NewAdmissionBucket(bucket.Config{Client: clients[i], Namespace: namespace})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := a.Run(context.Background()); err != nil {
+				t.Errorf("Run() in %s returned error %v", namespace, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, namespace := range namespaces {
+		created := 0
+		for _, action := range clients[i].Actions() {
+			create, ok := action.(k8stesting.CreateAction)
+			if !ok || action.GetResource().Resource != "pods" {
+				continue
+			}
+			created++
+			if pod := create.GetObject().(*v1.Pod); create.GetNamespace() != namespace || pod.Namespace != namespace {
+				t.Errorf("pod %s created in %s with namespace %s, want %s", pod.GenerateName, create.GetNamespace(), pod.Namespace, namespace)
+			}
+		}
+		if created == 0 {
+			t.Errorf("no pod was created in %s", namespace)
+		}
+	}
+}
+
+// dryRunFactory opts out of the real creation.
+type dryRunFactory struct {
+	testFactory
+}
+
+func (f dryRunFactory) DryRunOnly() bool {
+	return true
+}
+
+func TestScanDryRunOnly(t *testing.T) {
+	a := Bucket{
+		client:          fake.NewSimpleClientset(),
+		namespace:       "default",
+		podFactoryChain: []PodFactory{testFactory("created"), dryRunFactory{testFactory("dry")}},
+		cleaningLock:    &sync.Mutex{},
+		config:          bucket.Config{AdmCreate: true},
+	}
+	results := a.scan(context.Background(), "default")
+	if results[0].mode != modeCreate || results[1].mode != modeDryRun {
+		t.Errorf("scan() modes are %s and %s, want %s and %s", results[0].mode, results[1].mode, modeCreate, modeDryRun)
+	}
+	// the fake clientset does not record the dry run option, only the really
+	// created pods are cleaned
+	if len(a.podsToClean) != 1 || a.podsToClean[0].Name != "created" {
+		t.Errorf("only the created pod should be cleaned, got %v", a.podsToClean)
+	}
+}
+
+var podsResource = schema.GroupResource{Resource: "pods"}
+
+// creationErrors are the errors returned by the API server for the pods with
+// the same name.
+var creationErrors = map[string]error{
+	"rbac":           kerrors.NewForbidden(podsResource, "", errors.New(`User "system:serviceaccount:default:default" cannot create resource "pods" in API group "" in the namespace "default"`)),
+	"podsecurity":    kerrors.NewForbidden(podsResource, "podsecurity", errors.New(`violates PodSecurity "restricted:latest": privileged`)),
+	"webhook":        kerrors.NewForbidden(podsResource, "webhook", errors.New(`admission webhook "validation.gatekeeper.sh" denied the request: privileged containers are not allowed`)),
+	"webhookfailure": kerrors.NewInternalError(errors.New(`failed calling webhook "validate.kyverno.svc": connection refused`)),
+	"unauthorized":   kerrors.NewUnauthorized("invalid bearer token"),
+	"timeout":        kerrors.NewTimeoutError("request did not complete", 1),
+	"unreachable":    errors.New("dial tcp 10.96.0.1:443: connect: connection refused"),
+}
+
+func TestOutcome(t *testing.T) {
+	want := map[string]string{
+		"rbac":           outcomeForbidden,
+		"podsecurity":    outcomeDenied,
+		"webhook":        outcomeDenied,
+		"webhookfailure": outcomeError,
+		"unauthorized":   outcomeForbidden,
+		"timeout":        outcomeTimeout,
+		"unreachable":    outcomeError,
+	}
+	for name, err := range creationErrors {
+		if got := outcome(err); got != want[name] {
+			t.Errorf("outcome(%s) = %s, want %s", name, got, want[name])
+		}
+	}
+	if got := outcome(nil); got != outcomeAllowed {
+		t.Errorf("outcome(nil) = %s, want %s", got, outcomeAllowed)
+	}
+	if got := outcome(fmt.Errorf("pod creation did not return: %w", context.DeadlineExceeded)); got != outcomeTimeout {
+		t.Errorf("outcome(deadline) = %s, want %s", got, outcomeTimeout)
+	}
+}
+
+func TestScanOutcomes(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if err, ok := creationErrors[action.(k8stesting.CreateAction).GetObject().(*v1.Pod).Name]; ok {
+			return true, nil, err
+		}
+		return false, nil, nil
+	})
+
+	factories := []PodFactory{testFactory("allowed"), testFactory("webhook"), testFactory("rbac"), testFactory("unreachable")}
+	a := Bucket{
+		client:          client,
+		namespace:       "default",
+		podFactoryChain: factories,
+		cleaningLock:    &sync.Mutex{},
+	}
+	results := a.scan(context.Background(), "default")
+	want := []string{outcomeAllowed, outcomeDenied, outcomeForbidden, outcomeError}
+	for i, r := range results {
+		if got := outcome(r.err); got != want[i] {
+			t.Errorf("outcome of %s = %s, want %s", r.pod, got, want[i])
+		}
+	}
+}
+
+func TestCreateRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		errs        []error
+		wantCreates int
+		wantErr     bool
+	}{
+		{"throttled then created", []error{kerrors.NewTooManyRequests("slow down", 0)}, 2, false},
+		{"retries exhausted", []error{kerrors.NewServerTimeout(podsResource, "create", 0), kerrors.NewServerTimeout(podsResource, "create", 0), kerrors.NewServerTimeout(podsResource, "create", 0)}, 3, true},
+		{"denied", []error{creationErrors["webhook"]}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			creates := 0
+			client.PrependReactor("create", "pods", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+				creates++
+				if creates <= len(tt.errs) {
+					return true, nil, tt.errs[creates-1]
+				}
+				return false, nil, nil
+			})
+
+			a := Bucket{
+				client:       client,
+				namespace:    "default",
+				cleaningLock: &sync.Mutex{},
+				config:       bucket.Config{AdmRetries: 2, AdmRetryBackoff: time.Millisecond},
+			}
+			err := a.use(context.Background(), testFactory("retried"), "default")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("use() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if creates != tt.wantCreates {
+				t.Errorf("use() created %d times, want %d", creates, tt.wantCreates)
+			}
+		})
+	}
+}
+
+func TestRunAllNamespaces(t *testing.T) {
+	var objects []runtime.Object
+	for _, name := range []string{"team-a", "team-b", "restricted"} {
+		objects = append(objects, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	client := fake.NewSimpleClientset(objects...)
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "restricted" {
+			return true, nil, creationErrors["rbac"]
+		}
+		// the tracker does not generate the names
+		return true, action.(k8stesting.CreateAction).GetObject(), nil
+	})
+
+	// the real creation is ignored when scanning all the namespaces
+	a, err := NewAdmissionBucket(bucket.Config{Client: client, Namespace: "team-a", AdmAllNamespaces: true, AdmCreate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := a.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(a.podsToClean) != 0 {
+		t.Errorf("Run() created %d pods, want only dry runs", len(a.podsToClean))
+	}
+
+	raw, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Comments []string        `json:"comments"`
+		Headers  []string        `json:"headers"`
+		Content  [][]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &output); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Headers) == 0 || output.Headers[0] != "namespace" {
+		t.Fatalf("Run() headers = %v, want the namespace first", output.Headers)
+	}
+	rows := map[string]int{}
+	for _, row := range output.Content {
+		rows[row[0].(string)]++
+		if row[2] != modeDryRun {
+			t.Errorf("row %v was not created with dry run", row)
+		}
+	}
+	if rows["team-a"] == 0 || rows["team-a"] != rows["team-b"] || rows["restricted"] != 0 {
+		t.Errorf("Run() rows per namespace = %v, want the same for team-a and team-b and none for restricted", rows)
+	}
+	if len(output.Comments) != 1 || !strings.Contains(output.Comments[0], "restricted") {
+		t.Errorf("Run() comments = %q, want the skipped restricted namespace", output.Comments)
+	}
+}
+
+func TestScanLateCreation(t *testing.T) {
+	previous := podFactoryTimeout
+	podFactoryTimeout = 10 * time.Millisecond
+	defer func() { podFactoryTimeout = previous }()
+
+	client := fake.NewSimpleClientset()
+	// the creation of late ignores its context and succeeds after the
+	// collection gave up
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*v1.Pod)
+		if pod.Name == "late" {
+			time.Sleep(podFactoryTimeout + collectGracePeriod + 50*time.Millisecond)
+		}
+		return false, nil, nil
+	})
+
+	a := Bucket{
+		client:          client,
+		namespace:       "default",
+		podFactoryChain: []PodFactory{testFactory("late")},
+		cleaningLock:    &sync.Mutex{},
+		config:          bucket.Config{AdmCreate: true},
+	}
+	results := a.scan(context.Background(), "default")
+	if results[0].err == nil {
+		t.Errorf("late factory should be reported as not returned, got %+v", results[0])
+	}
+	if err := a.Cleanup(context.Background()); err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	deleted := false
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "delete" {
+			deleted = true
+		}
+	}
+	if !deleted {
+		t.Error("the pod created after the collection was not deleted")
+	}
+}
+
+func TestManifestPodNamespace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pod.yaml")
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: production
+spec:
+  containers:
+  - name: web
+    image: nginx
+`
+	if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := newManifestPod(path)
+	if err != nil {
+		t.Fatalf("newManifestPod() error = %v", err)
+	}
+	pod := f.NewPod("team-a")
+	if pod.Namespace != "team-a" {
+		t.Errorf("NewPod() namespace = %q, want the scanned one", pod.Namespace)
+	}
+	if f.pod.Namespace != "production" {
+		t.Errorf("NewPod() modified the manifest namespace to %q", f.pod.Namespace)
+	}
+}
@@ -0,0 +1,161 @@
+package anonymous
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/environment"
+)
+
+const (
+	bucketName        = "anonymous"
+	bucketDescription = "Anonymous requests the API server endpoints and resources without credentials to check if anonymous authentication is enabled and what it can read."
+
+	caPath = "/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	kubernetesPortEnv = "KUBERNETES_SERVICE_PORT"
+
+	metricsEndpoint = "/metrics"
+
+	requestTimeout = 2 * time.Second
+)
+
+var bucketAliases = []string{"anon", "anonymousauth"}
+
+// endpoint is requested anonymously, the severity is raised if it answers,
+// the read of resources being worse than the leak of information.
+type endpoint struct {
+	path     string
+	severity bucket.Severity
+}
+
+var endpoints = []endpoint{
+	{"/version", bucket.SeverityNone},
+	{"/healthz", bucket.SeverityNone},
+	{"/livez", bucket.SeverityNone},
+	{"/readyz", bucket.SeverityNone},
+	{metricsEndpoint, bucket.SeverityMedium},
+	{"/api/v1/namespaces", bucket.SeverityHigh},
+	{"/api/v1/nodes", bucket.SeverityHigh},
+	{"/api/v1/pods", bucket.SeverityHigh},
+	{"/api/v1/secrets", bucket.SeverityCritical},
+}
+
+type Bucket struct{}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	host := os.Getenv(environment.KubernetesHostEnv)
+	port := os.Getenv(kubernetesPortEnv)
+	if host == "" || port == "" {
+		return bucket.Results{}, fmt.Errorf("%s or %s env var not found, not running inside a pod", environment.KubernetesHostEnv, kubernetesPortEnv)
+	}
+	server := "https://" + net.JoinHostPort(host, port)
+
+	caPEM, err := os.ReadFile(caPath)
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to read the mounted CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+
+	// a bare client is used on purpose, the kubernetes client would add the
+	// service account token to the requests
+	client := &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+
+	return probe(ctx, client, server), nil
+}
+
+// probe requests the endpoints on server with client, that must not carry
+// any credentials.
+func probe(ctx context.Context, client *http.Client, server string) bucket.Results {
+	res := bucket.NewResults(bucketName)
+	res.SetHeaders([]string{"endpoint", "anonymousAccessible", "status", bucket.ErrorHeader})
+	// only the responses of the API server tell if anonymous authentication
+	// is enabled, the failed requests can't be used for a conclusion
+	anonymousEnabled := false
+	unauthorized, failed := 0, 0
+	var resources []string
+	for _, e := range endpoints {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server+e.path, nil)
+		if err != nil {
+			res.AddContentWithError([]interface{}{e.path, false, ""}, err)
+			failed++
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			res.AddContentWithError([]interface{}{e.path, false, ""}, err)
+			failed++
+			continue
+		}
+		resp.Body.Close()
+
+		accessible := resp.StatusCode == http.StatusOK
+		// a forbidden response means that the request was authenticated as
+		// system:anonymous but not authorized
+		if resp.StatusCode == http.StatusUnauthorized {
+			unauthorized++
+		} else {
+			anonymousEnabled = true
+		}
+		res.AddContentWithError([]interface{}{e.path, accessible, resp.Status}, nil)
+
+		if !accessible {
+			continue
+		}
+		res.RaiseSeverity(e.severity)
+		switch {
+		case e.path == metricsEndpoint:
+			res.AddComment("The metrics endpoint is accessible anonymously, it leaks internals of the cluster.")
+		case e.severity >= bucket.SeverityHigh:
+			resources = append(resources, e.path)
+		}
+	}
+
+	if len(resources) > 0 {
+		res.AddComment(fmt.Sprintf("Resources of the cluster can be read without credentials, system:anonymous or system:unauthenticated is granted read access: %v.", resources))
+	}
+	switch {
+	case anonymousEnabled:
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("Anonymous authentication is enabled on the API server, requests without credentials are authenticated as system:anonymous.")
+	case unauthorized > 0:
+		res.AddComment("Anonymous authentication seems disabled on the API server, requests without credentials are rejected as unauthorized.")
+	default:
+		res.AddComment("No request reached the API server, anonymous authentication could not be checked.")
+	}
+	if failed > 0 {
+		res.AddComment(fmt.Sprintf("%d of the requests failed, see the error column.", failed))
+	}
+
+	return *res
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewAnonymousBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: false,
+	})
+}
+
+func NewAnonymousBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,102 @@
+package anonymous
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+func TestProbe(t *testing.T) {
+	// the API server lets anonymous users read the namespaces only
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("request to %s carries credentials", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/version", "/healthz", "/api/v1/namespaces":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	res := probe(context.Background(), server.Client(), server.URL)
+	if res.Severity() != bucket.SeverityHigh {
+		t.Errorf("probe() severity = %s, want %s", res.Severity(), bucket.SeverityHigh)
+	}
+	raw, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Content [][]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &output); err != nil {
+		t.Fatal(err)
+	}
+	accessible := map[string]bool{}
+	for _, row := range output.Content {
+		accessible[row[0].(string)] = row[1].(bool)
+	}
+	for _, e := range endpoints {
+		want := e.path == "/version" || e.path == "/healthz" || e.path == "/api/v1/namespaces"
+		if accessible[e.path] != want {
+			t.Errorf("endpoint %s accessible = %v, want %v", e.path, accessible[e.path], want)
+		}
+	}
+}
+
+func TestProbeDisabled(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	res := probe(context.Background(), server.Client(), server.URL)
+	if res.Severity() != bucket.SeverityNone {
+		t.Errorf("probe() severity = %s, want %s", res.Severity(), bucket.SeverityNone)
+	}
+}
+
+func TestProbeUnreachable(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	client, url := server.Client(), server.URL
+	// the requests fail before reaching the API server
+	server.Close()
+
+	res := probe(context.Background(), client, url)
+	raw, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Comments []string        `json:"comments"`
+		Headers  []string        `json:"headers"`
+		Content  [][]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &output); err != nil {
+		t.Fatal(err)
+	}
+	errorColumn := len(output.Headers) - 1
+	if output.Headers[errorColumn] != bucket.ErrorHeader {
+		t.Fatalf("probe() headers = %v, want the error column last", output.Headers)
+	}
+	for _, row := range output.Content {
+		if row[errorColumn] == "" {
+			t.Errorf("endpoint %s has no error", row[0])
+		}
+	}
+	for _, comment := range output.Comments {
+		if strings.Contains(comment, "disabled") {
+			t.Errorf("probe() concluded from transport errors: %q", comment)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package apiresources
 
 import (
+	"context"
+
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
@@ -15,7 +17,7 @@ type Bucket struct {
 	config bucket.Config
 }
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	// executes here the code of your plugin
 	res := bucket.NewResults(bucketName)
 
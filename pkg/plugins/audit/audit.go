@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "audit"
+	bucketDescription = "Audit checks if the container can interact with the host audit subsystem through a netlink socket."
+)
+
+var bucketAliases = []string{"auditd", "auditnetlink"}
+
+type Bucket struct{}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewAuditBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewAuditBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
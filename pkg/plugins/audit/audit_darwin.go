@@ -0,0 +1,12 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	return bucket.Results{}, errors.New("audit check is not supported on macOS")
+}
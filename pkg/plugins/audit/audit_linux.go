@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/capabilities"
+	"github.com/syndtr/gocapability/capability"
+	"golang.org/x/sys/unix"
+)
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	caps, err := capabilities.GetCapabilities(0)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	effective := map[capability.Cap]bool{}
+	for _, c := range caps[capability.EFFECTIVE] {
+		effective[c] = true
+	}
+
+	socketOpened := true
+	status := "accepted"
+	statusErr := queryStatus()
+	var openErr *socketError
+	if errors.As(statusErr, &openErr) {
+		socketOpened = false
+	}
+	if statusErr != nil {
+		status = statusErr.Error()
+	}
+
+	res.SetHeaders([]string{"capAuditControl", "capAuditWrite", "capAuditRead", "socketOpened", "statusQuery"})
+	res.AddContent([]interface{}{
+		effective[capability.CAP_AUDIT_CONTROL],
+		effective[capability.CAP_AUDIT_WRITE],
+		effective[capability.CAP_AUDIT_READ],
+		socketOpened,
+		status,
+	})
+
+	if statusErr == nil {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment("The audit status query was accepted, the container talks to the host audit subsystem and could tamper with the audit rules and logs.")
+	} else if socketOpened && effective[capability.CAP_AUDIT_WRITE] {
+		res.AddComment("CAP_AUDIT_WRITE is present, if the container shares the host user namespace it could write user messages to the host audit logs.")
+	}
+
+	return *res, nil
+}
+
+// socketError wraps the errors happening before the request is sent.
+type socketError struct {
+	err error
+}
+
+func (e *socketError) Error() string {
+	return fmt.Sprintf("failed to open audit netlink socket: %s", e.err)
+}
+
+// queryStatus sends an AUDIT_GET request, the read only equivalent of
+// "auditctl -s". The kernel only answers it in the initial user and PID
+// namespaces with CAP_AUDIT_CONTROL.
+func queryStatus() error {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_AUDIT)
+	if err != nil {
+		return &socketError{err}
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return &socketError{err}
+	}
+	timeout := unix.Timeval{Sec: 1}
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &timeout); err != nil {
+		return &socketError{err}
+	}
+
+	request := make([]byte, unix.NLMSG_HDRLEN)
+	binary.NativeEndian.PutUint32(request[0:4], unix.NLMSG_HDRLEN)
+	binary.NativeEndian.PutUint16(request[4:6], unix.AUDIT_GET)
+	binary.NativeEndian.PutUint16(request[6:8], unix.NLM_F_REQUEST|unix.NLM_F_ACK)
+	binary.NativeEndian.PutUint32(request[8:12], 1)
+	if err := unix.Sendto(fd, request, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, unix.Getpagesize())
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			switch msg.Header.Type {
+			case unix.AUDIT_GET:
+				return nil
+			case unix.NLMSG_ERROR:
+				if len(msg.Data) < 4 {
+					return errors.New("truncated netlink error message")
+				}
+				// the ack is an error message with a zero errno
+				errno := -int32(binary.NativeEndian.Uint32(msg.Data[0:4]))
+				if errno != 0 {
+					return syscall.Errno(errno)
+				}
+			}
+		}
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
 	v1 "k8s.io/api/authorization/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/kubectl/pkg/describe"
 	rbacutil "k8s.io/kubectl/pkg/util/rbac"
 )
@@ -24,7 +26,51 @@ type Bucket struct {
 	config bucket.Config
 }
 
-func (n Bucket) Run() (bucket.Results, error) {
+// check is an entry of the access matrix, verbs are checked on the resource
+// in the namespace of the bucket unless it is cluster scoped.
+type check struct {
+	group       string
+	resource    string
+	subresource string
+	verbs       []string
+	cluster     bool
+}
+
+// accessMatrix lists the common verbs and resources to check, focused on the
+// ones useful to an attacker.
+var accessMatrix = []check{
+	{"", "pods", "", []string{"get", "list", "create", "update", "delete"}, false},
+	{"", "pods", "exec", []string{"create"}, false},
+	{"", "pods", "log", []string{"get"}, false},
+	{"", "secrets", "", []string{"get", "list", "create", "update", "delete"}, false},
+	{"", "configmaps", "", []string{"get", "list", "create", "update", "delete"}, false},
+	{"", "serviceaccounts", "token", []string{"create"}, false},
+	{"apps", "deployments", "", []string{"get", "list", "create", "update", "delete"}, false},
+	{"apps", "daemonsets", "", []string{"create"}, false},
+	{"rbac.authorization.k8s.io", "roles", "", []string{"create", "bind", "escalate"}, false},
+	{"rbac.authorization.k8s.io", "rolebindings", "", []string{"create"}, false},
+	{"", "nodes", "", []string{"get", "list"}, true},
+	{"", "nodes", "proxy", []string{"get"}, true},
+	{"", "namespaces", "", []string{"list"}, true},
+	{"rbac.authorization.k8s.io", "clusterroles", "", []string{"create", "bind", "escalate"}, true},
+	{"rbac.authorization.k8s.io", "clusterrolebindings", "", []string{"create"}, true},
+	{"", "serviceaccounts", "", []string{"impersonate"}, false},
+	{"certificates.k8s.io", "certificatesigningrequests", "", []string{"create"}, true},
+}
+
+// access is the decision of the access review for a verb on a resource.
+type access struct {
+	resource string
+	verb     string
+	allowed  bool
+	err      error
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	if n.config.AuthorizationMatrix {
+		return n.runMatrix(ctx)
+	}
+
 	res := bucket.NewResults(bucketName)
 
 	// create the self subject rules review object
@@ -38,7 +84,7 @@ func (n Bucket) Run() (bucket.Results, error) {
 
 	// do the actual request
 	response, err := n.config.Client.AuthorizationV1().SelfSubjectRulesReviews().Create(
-		context.TODO(),
+		ctx,
 		obj,
 		metav1.CreateOptions{},
 	)
@@ -67,6 +113,66 @@ func (n Bucket) Run() (bucket.Results, error) {
 	return *res, nil
 }
 
+// runMatrix checks every entry of the access matrix with a
+// SelfSubjectAccessReview, concurrently.
+func (n Bucket) runMatrix(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+	res.AddComment(fmt.Sprintf("Checking common verbs and resources with access reviews in the %q namespace.", n.config.Namespace))
+
+	var accesses []access
+	var attributes []v1.ResourceAttributes
+	for _, c := range accessMatrix {
+		namespace := n.config.Namespace
+		resource := c.resource
+		if c.group != "" {
+			resource += "." + c.group
+		}
+		if c.subresource != "" {
+			resource += "/" + c.subresource
+		}
+		if c.cluster {
+			namespace = ""
+			resource += " (cluster)"
+		}
+		for _, verb := range c.verbs {
+			accesses = append(accesses, access{resource: resource, verb: verb})
+			attributes = append(attributes, v1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       c.group,
+				Resource:    c.resource,
+				Subresource: c.subresource,
+			})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range accesses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			accesses[i].allowed, _, accesses[i].err = CanI(ctx, n.config.Client, attributes[i])
+		}(i)
+	}
+	wg.Wait()
+
+	res.SetHeaders([]string{"resource", "verb", "allowed"})
+	allowed := 0
+	for _, a := range accesses {
+		if a.err != nil {
+			// the access reviews are allowed to everyone, a failure is
+			// probably not specific to this check
+			return bucket.Results{}, fmt.Errorf("access review for %s on %s failed: %w", a.verb, a.resource, a.err)
+		}
+		if a.allowed {
+			allowed++
+		}
+		res.AddContent([]interface{}{a.resource, a.verb, a.allowed})
+	}
+	res.AddComment(fmt.Sprintf("%d of the %d checked accesses are allowed.", allowed, len(accesses)))
+	return *res, nil
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
@@ -89,6 +195,26 @@ func NewAuthorizationBucket(c bucket.Config) (*Bucket, error) {
 	}, nil
 }
 
+// CanI checks with a SelfSubjectAccessReview if the current identity is
+// allowed to perform the action described by the attributes. It returns the
+// decision and its reason, if the authorizer gave any.
+func CanI(ctx context.Context, client kubernetes.Interface, attributes v1.ResourceAttributes) (bool, string, error) {
+	review := &v1.SelfSubjectAccessReview{
+		Spec: v1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+		},
+	}
+	response, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(
+		ctx,
+		review,
+		metav1.CreateOptions{},
+	)
+	if err != nil {
+		return false, "", err
+	}
+	return response.Status.Allowed, response.Status.Reason, nil
+}
+
 // partial copy of https://github.com/kubernetes/kubectl/blob/0f88fc6b598b7e883a391a477215afb080ec7733/pkg/cmd/auth/cani.go#L323
 func getCompactRules(status v1.SubjectRulesReviewStatus) ([]rbacv1.PolicyRule, string, error) {
 	if status.Incomplete {
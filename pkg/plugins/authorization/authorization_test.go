@@ -0,0 +1,56 @@
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRunMatrix(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// only listing pods in the default namespace is allowed
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*v1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = attributes.Namespace == "default" && attributes.Resource == "pods" && attributes.Subresource == "" && attributes.Verb == "list"
+		return true, review, nil
+	})
+
+	b, err := NewAuthorizationBucket(bucket.Config{Client: client, Namespace: "default", AuthorizationMatrix: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	raw, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output struct {
+		Content [][]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &output); err != nil {
+		t.Fatal(err)
+	}
+	var allowed [][]interface{}
+	for _, row := range output.Content {
+		if row[2] == true {
+			allowed = append(allowed, row)
+		}
+	}
+	if len(allowed) != 1 || allowed[0][0] != "pods" || allowed[0][1] != "list" {
+		t.Errorf("allowed rows = %v, want only pods list", allowed)
+	}
+	if len(output.Content) < len(accessMatrix) {
+		t.Errorf("got %d rows, want at least one per matrix entry", len(output.Content))
+	}
+}
@@ -0,0 +1,111 @@
+package binfmt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/mount"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "binfmt"
+	bucketDescription = "Binfmt checks if binfmt_misc is mounted and writable, allowing to register an interpreter executed by the host."
+
+	binfmtFilesystem = "binfmt_misc"
+	binfmtPath       = "/proc/sys/fs/binfmt_misc"
+	registerFile     = "register"
+	statusFile       = "status"
+)
+
+var bucketAliases = []string{"binfmtmisc", "binfmt_misc"}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	mnts, err := mount.Mounts()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	var binfmts []mount.Mount
+	for _, m := range mnts {
+		if m.Filesystem == binfmtFilesystem {
+			binfmts = append(binfmts, m)
+		}
+	}
+	if len(binfmts) == 0 {
+		// /proc/sys/fs/binfmt_misc is an automount point, it might exist
+		// without being mounted yet
+		binfmts = append(binfmts, mount.Mount{Path: binfmtPath})
+	}
+
+	res.SetHeaders([]string{"path", "mounted", "writable", "entries", "status"})
+	writable := false
+	for _, m := range binfmts {
+		mounted := m.Filesystem == binfmtFilesystem
+		register := filepath.Join(m.Path, registerFile)
+		// access only checks the permissions, nothing is registered
+		w := mounted && m.ReadWrite() && unix.Access(register, unix.W_OK) == nil
+		writable = writable || w
+
+		entries, status := "", ""
+		if mounted {
+			names, err := readEntries(m.Path)
+			if err != nil {
+				entries = err.Error()
+			} else {
+				entries = fmt.Sprint(len(names))
+			}
+			if content, err := os.ReadFile(filepath.Join(m.Path, statusFile)); err == nil {
+				status = strings.TrimSpace(string(content))
+			}
+		}
+		res.AddContent([]interface{}{m.Path, mounted, w, entries, status})
+	}
+
+	if writable {
+		res.RaiseSeverity(bucket.SeverityCritical)
+		res.AddComment("binfmt_misc is writable, an interpreter registered with the F flag would be executed by the host kernel for matching binaries, this is a known container escape.")
+	}
+
+	return *res, nil
+}
+
+// readEntries returns the names of the registered interpreters.
+func readEntries(path string) ([]string, error) {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range dirEntries {
+		if e.Name() != registerFile && e.Name() != statusFile {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewBinfmtBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewBinfmtBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -2,6 +2,7 @@ package capabilities
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -17,7 +18,43 @@ const (
 	bucketDescription = "Capabilities lists all capabilities in all sets and displays dangerous capabilities in red."
 )
 
-var bucketAliases = []string{"capability", "cap"}
+var bucketAliases = []string{"capability", "cap", "caps"}
+
+// capSets are the sets of /proc/self/status in display order.
+var capSets = []capability.CapType{
+	capability.EFFECTIVE,
+	capability.PERMITTED,
+	capability.INHERITABLE,
+	capability.BOUNDING,
+	capability.AMBIENT,
+}
+
+// DefaultCaps is the default set granted by containerd, CRI-O and Docker to
+// non-privileged containers.
+var DefaultCaps = []capability.Cap{
+	capability.CAP_CHOWN,
+	capability.CAP_DAC_OVERRIDE,
+	capability.CAP_FSETID,
+	capability.CAP_FOWNER,
+	capability.CAP_MKNOD,
+	capability.CAP_NET_RAW,
+	capability.CAP_SETGID,
+	capability.CAP_SETUID,
+	capability.CAP_SETFCAP,
+	capability.CAP_SETPCAP,
+	capability.CAP_NET_BIND_SERVICE,
+	capability.CAP_SYS_CHROOT,
+	capability.CAP_KILL,
+	capability.CAP_AUDIT_WRITE,
+}
+
+// extraSets are the sets compared to the runtime default, the bounding set
+// limits what the container can ever get and the effective set is what it
+// holds right now.
+var extraSets = []capability.CapType{
+	capability.BOUNDING,
+	capability.EFFECTIVE,
+}
 
 var dangerousCap = []capability.Cap{
 	capability.CAP_CHOWN,
@@ -35,8 +72,8 @@ var dangerousCap = []capability.Cap{
 
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
-	capabilities, err := getCapabilities(0)
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	capabilities, err := GetCapabilities(0)
 
 	if err != nil {
 		return bucket.Results{}, err
@@ -46,7 +83,8 @@ func (n Bucket) Run() (bucket.Results, error) {
 	res.SetHeaders([]string{"set", "capabilities"})
 	var colors text.Colors
 	colors = append(colors, text.FgRed)
-	for set, caps := range capabilities {
+	for _, set := range capSets {
+		caps := capabilities[set]
 		var sCaps = []string{}
 		for _, cap := range caps {
 			if isDangerousCap(cap) {
@@ -58,12 +96,47 @@ func (n Bucket) Run() (bucket.Results, error) {
 		res.AddContent([]interface{}{set.String(), sCaps})
 	}
 
+	var dangerous []string
+	for _, cap := range capabilities[capability.EFFECTIVE] {
+		if isDangerousCap(cap) {
+			dangerous = append(dangerous, "CAP_"+strings.ToUpper(cap.String()))
+		}
+	}
+	if len(dangerous) > 0 {
+		res.AddComment(fmt.Sprintf("The effective set contains dangerous capabilities: %v.", dangerous))
+	}
+
 	if isPrivileged(capabilities[capability.BOUNDING]) {
 		res.AddComment(fmt.Sprintf("The bounding set contains %d caps and you have CAP_SYS_ADMIN, you might be running a privileged container, check the number of devices available.", len(capabilities[capability.BOUNDING])))
 	} else {
 		res.AddComment(fmt.Sprintf("The bounding set contains %d caps, it seems that you are running a non-privileged container.", len(capabilities[capability.BOUNDING])))
 	}
 
+	// list the capabilities granted beyond the default of the runtimes, they
+	// were added on purpose or by a misconfiguration
+	extra := bucket.NewResults("extra capabilities")
+	extra.SetHeaders([]string{"set", "capability", "dangerous"})
+	extraCount := 0
+	for _, set := range extraSets {
+		caps := ExtraCaps(capabilities[set])
+		extraCount += len(caps)
+		for _, cap := range caps {
+			extra.AddContent([]interface{}{set.String(), "CAP_" + strings.ToUpper(cap.String()), isDangerousCap(cap)})
+			if isDangerousCap(cap) {
+				extra.RaiseSeverity(bucket.SeverityHigh)
+			}
+		}
+		if len(caps) > 0 {
+			extra.RaiseSeverity(bucket.SeverityMedium)
+			extra.AddComment(fmt.Sprintf("The %s set contains %d capabilities beyond the runtime default set.", set, len(caps)))
+		}
+	}
+	if extraCount > 0 {
+		res.AddSubResults(*extra)
+	} else {
+		res.AddComment("The bounding set does not exceed the default set of the container runtimes.")
+	}
+
 	noNewPrivs, err := readNoNewPrivsFlag()
 	if err != nil {
 		// this is an additional feature, do not "error" on this
@@ -95,6 +168,22 @@ func NewCapabilitiesBucket(config bucket.Config) (*Bucket, error) {
 	return &Bucket{}, nil
 }
 
+// ExtraCaps returns the capabilities of caps that are not in the runtime
+// default set.
+func ExtraCaps(caps []capability.Cap) []capability.Cap {
+	var extra []capability.Cap
+	for _, c := range caps {
+		isDefault := false
+		for _, d := range DefaultCaps {
+			isDefault = isDefault || c == d
+		}
+		if !isDefault {
+			extra = append(extra, c)
+		}
+	}
+	return extra
+}
+
 func isDangerousCap(cap capability.Cap) bool {
 	for _, dCap := range dangerousCap {
 		if cap == dCap {
@@ -115,9 +204,9 @@ func isPrivileged(caps []capability.Cap) bool {
 	return false
 }
 
-// getCapabilities returns the allowed capabilities for the process.
+// GetCapabilities returns the allowed capabilities for the process.
 // If pid is less zero, it returns the capabilities for "self".
-func getCapabilities(pid int) (map[capability.CapType][]capability.Cap, error) {
+func GetCapabilities(pid int) (map[capability.CapType][]capability.Cap, error) {
 	allCaps := capability.List()
 
 	caps, err := capability.NewPid2(pid)
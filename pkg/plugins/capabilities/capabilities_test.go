@@ -0,0 +1,27 @@
+package capabilities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/syndtr/gocapability/capability"
+)
+
+func TestExtraCaps(t *testing.T) {
+	tests := []struct {
+		name string
+		caps []capability.Cap
+		want []capability.Cap
+	}{
+		{"empty", nil, nil},
+		{"default", DefaultCaps, nil},
+		{"added", []capability.Cap{capability.CAP_CHOWN, capability.CAP_SYS_ADMIN, capability.CAP_NET_ADMIN}, []capability.Cap{capability.CAP_SYS_ADMIN, capability.CAP_NET_ADMIN}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtraCaps(tt.caps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtraCaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
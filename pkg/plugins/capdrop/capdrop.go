@@ -0,0 +1,147 @@
+package capdrop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/capabilities"
+	"github.com/syndtr/gocapability/capability"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	bucketName        = "capdrop"
+	bucketDescription = "CapDrop grades the capability hardening of the container, checking if it drops ALL capabilities and adds back only the needed ones."
+
+	sourceBounding = "bounding"
+	sourceSpec     = "spec:"
+)
+
+var bucketAliases = []string{"capsdrop", "dropall"}
+
+// minimalCaps are capabilities commonly added back after dropping ALL
+// because they are needed by regular workloads and hardly abusable.
+var minimalCaps = []capability.Cap{
+	capability.CAP_NET_BIND_SERVICE,
+}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	caps, err := capabilities.GetCapabilities(0)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	bounding := caps[capability.BOUNDING]
+
+	res.SetHeaders([]string{"source", "retained", "added", "dropsAll", "grade"})
+	g := grade(bounding)
+	res.AddContent([]interface{}{sourceBounding, len(bounding), "", len(bounding) == 0, g})
+
+	switch g {
+	case "A", "B":
+		res.AddComment("The bounding set is empty or minimal, the container seems to drop ALL capabilities.")
+	case "C":
+		res.AddComment(fmt.Sprintf("The bounding set contains %d caps, fewer than the %d default ones, but ALL were not dropped.", len(bounding), len(capabilities.DefaultCaps)))
+	case "D":
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("The bounding set is the runtime default one, no capability was dropped.")
+	default:
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("The bounding set contains capabilities beyond the runtime default: %v.", capabilities.ExtraCaps(bounding)))
+	}
+
+	// the spec is only a cross-check, the bounding set is what really applies
+	// to the process
+	client, err := n.config.OptionalClient()
+	if err != nil {
+		res.AddComment(fmt.Sprintf("No client could be loaded, the securityContext of the pod was not checked: %s", err))
+		return *res, nil
+	}
+	pod, err := automaticontext.CurrentPod(ctx, client, n.config.Namespace)
+	if err != nil {
+		res.AddComment(fmt.Sprintf("Failed to retrieve the pod spec, the securityContext was not checked: %s", err))
+		return *res, nil
+	}
+	for _, c := range pod.Spec.Containers {
+		var drop, add []v1.Capability
+		if c.SecurityContext != nil && c.SecurityContext.Capabilities != nil {
+			drop = c.SecurityContext.Capabilities.Drop
+			add = c.SecurityContext.Capabilities.Add
+		}
+		res.AddContent([]interface{}{sourceSpec + c.Name, "", add, dropsAll(drop), ""})
+	}
+
+	return *res, nil
+}
+
+// grade gives a letter to the bounding set: A for an empty set, B for a
+// minimal one, C for a reduced default set, D for the default set and F for
+// more than the default set.
+func grade(bounding []capability.Cap) string {
+	switch {
+	case len(bounding) == 0:
+		return "A"
+	case len(capabilities.ExtraCaps(bounding)) > 0:
+		return "F"
+	case isSubset(bounding, minimalCaps):
+		return "B"
+	case len(bounding) < len(capabilities.DefaultCaps):
+		return "C"
+	default:
+		return "D"
+	}
+}
+
+func isSubset(caps []capability.Cap, set []capability.Cap) bool {
+	for _, c := range caps {
+		if !contains(set, c) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(caps []capability.Cap, c capability.Cap) bool {
+	for _, cap := range caps {
+		if cap == c {
+			return true
+		}
+	}
+	return false
+}
+
+func dropsAll(drop []v1.Capability) bool {
+	for _, c := range drop {
+		if strings.EqualFold(string(c), "ALL") {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewCapDropBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewCapDropBucket(config bucket.Config) (*Bucket, error) {
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -0,0 +1,126 @@
+package capinning
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/environment"
+)
+
+const (
+	bucketName        = "capinning"
+	bucketDescription = "CAPinning checks if the API server certificate only validates against the mounted CA and not the system cert pool."
+
+	caPath = "/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+	kubernetesPortEnv = "KUBERNETES_SERVICE_PORT"
+
+	handshakeTimeout = time.Second
+)
+
+var bucketAliases = []string{"pinning", "ca"}
+
+type Bucket struct{}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	host := os.Getenv(environment.KubernetesHostEnv)
+	port := os.Getenv(kubernetesPortEnv)
+	if host == "" || port == "" {
+		return bucket.Results{}, fmt.Errorf("%s or %s env var not found, not running inside a pod", environment.KubernetesHostEnv, kubernetesPortEnv)
+	}
+	endpoint := net.JoinHostPort(host, port)
+
+	caPEM, err := os.ReadFile(caPath)
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to read the mounted CA: %w", err)
+	}
+	fingerprint, err := fingerprint(caPEM)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	mountedPool := x509.NewCertPool()
+	mountedPool.AppendCertsFromPEM(caPEM)
+
+	mountedErr := handshake(ctx, endpoint, host, mountedPool)
+	// a nil pool means the system pool for crypto/tls
+	systemErr := handshake(ctx, endpoint, host, nil)
+
+	pinned := mountedErr == nil && systemErr != nil
+
+	res.SetHeaders([]string{"endpoint", "mountedCAValidates", "systemPoolValidates", "pinned", "caFingerprint"})
+	res.AddContent([]interface{}{endpoint, mountedErr == nil, systemErr == nil, pinned, fingerprint})
+
+	if mountedErr != nil {
+		res.AddComment(fmt.Sprintf("The API server certificate does not validate against the mounted CA: %s", mountedErr))
+	}
+	if systemErr == nil {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("The API server certificate validates against the system cert pool, any public CA could issue a certificate trusted by clients not using the mounted CA.")
+	} else if pinned {
+		res.AddComment("The cluster uses a private CA, only clients using the mounted bundle can validate the API server.")
+	}
+
+	return *res, nil
+}
+
+// handshake only performs the TLS handshake and closes the connection, no
+// request is sent to the API server.
+func handshake(ctx context.Context, endpoint string, serverName string, roots *x509.CertPool) error {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: handshakeTimeout},
+		Config: &tls.Config{
+			RootCAs:    roots,
+			ServerName: serverName,
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// fingerprint returns the SHA-256 fingerprint of the first certificate of
+// the PEM bundle, in the usual colon separated hexadecimal format.
+func fingerprint(caPEM []byte) (string, error) {
+	block, _ := pem.Decode(caPEM)
+	if block == nil {
+		return "", errors.New("failed to decode the mounted CA, no PEM block found")
+	}
+	sum := sha256.Sum256(block.Bytes)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":"), nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewCAPinningBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewCAPinningBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
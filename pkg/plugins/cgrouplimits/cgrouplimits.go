@@ -0,0 +1,87 @@
+package cgrouplimits
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/cgroups"
+	"github.com/quarkslab/kdigger/pkg/plugins/releaseagent"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "cgrouplimits"
+	bucketDescription = "CgroupLimits checks if the container can modify its own cgroup resource limits, writing back the current values."
+)
+
+var bucketAliases = []string{"cgrouplimit", "limits"}
+
+// limitFiles are relative to the cgroup root, v1 hierarchies are mounted per
+// controller.
+var (
+	limitFilesV2 = []string{"memory.max", "memory.high", "memory.swap.max", "cpu.max", "pids.max"}
+	limitFilesV1 = []string{"memory/memory.limit_in_bytes", "memory/memory.memsw.limit_in_bytes", "cpu/cpu.cfs_quota_us", "cpu/cpu.shares", "pids/pids.max"}
+)
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	files := limitFilesV1
+	if cgroups.IsV2() {
+		files = limitFilesV2
+	}
+
+	res.SetHeaders([]string{"file", "value", "writable", "writeAccepted"})
+	var accepted []string
+	for _, f := range files {
+		path := filepath.Join(cgroups.Root, f)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			// the controller might not be enabled or mounted
+			continue
+		}
+		writable := unix.Access(path, unix.W_OK) == nil
+		writeAccepted := false
+		if writable {
+			// writing back the same value is accepted by the kernel handler
+			// only if the file is really writable, without changing the limit
+			writeAccepted = releaseagent.RewriteFile(path) == nil
+		}
+		if writeAccepted {
+			accepted = append(accepted, f)
+		}
+		res.AddContent([]interface{}{f, strings.TrimSpace(string(content)), writable, writeAccepted})
+	}
+
+	if len(accepted) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("The container can modify its own limits, it could raise them and starve its neighbors: %v.", accepted))
+	} else {
+		res.AddComment("The container can't modify its own limits.")
+	}
+
+	return *res, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewCgroupLimitsBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: false,
+	})
+}
+
+func NewCgroupLimitsBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
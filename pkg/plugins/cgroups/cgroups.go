@@ -2,16 +2,33 @@ package cgroups
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
 	bucketName        = "cgroups"
-	bucketDescription = "Cgroups reads the /proc/self/cgroup files that can leak information under cgroups v1."
+	bucketDescription = "Cgroups reports the cgroup version and resource limits of the container and the /proc/self/cgroup paths that can leak information under cgroups v1."
+
+	// Root is where the cgroup filesystem is usually mounted
+	Root = "/sys/fs/cgroup"
+)
+
+const (
+	// unlimited is the value of the limit files without limit
+	unlimited = "max"
+	noLimit   = "no limit"
+	// unlimitedV1Memory is the lowest value of memory.limit_in_bytes without
+	// limit, the maximum value is rounded down to the page size
+	unlimitedV1Memory = 1 << 62
 )
 
 var bucketAliases = []string{"cgroup", "cg"}
@@ -24,25 +41,117 @@ type Cgroup struct {
 	CgroupPath     string
 }
 
-func (n Bucket) Run() (bucket.Results, error) {
-	// executes here the code of your plugin
+// limit is a resource limit of the container cgroup.
+type limit struct {
+	resource string
+	value    string
+	raw      string
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	cgroups, err := readCgroupFile()
 	if err != nil {
 		return bucket.Results{}, err
 	}
 
 	res := bucket.NewResults(bucketName)
-	if len(cgroups) <= 1 {
-		// https://stackoverflow.com/a/69005753
-		res.AddComment("This kernel might use cgroups v2, thus explaining the lack of information.")
+	if IsV2() {
+		res.AddComment("The container uses cgroups v2.")
+	} else {
+		res.AddComment("The container uses cgroups v1.")
 	}
+
 	res.SetHeaders([]string{"hierarchyID", "controllerList", "cgroupPath"})
 	for _, cgroup := range cgroups {
 		res.AddContent([]interface{}{cgroup.HierarchyID, cgroup.ControllerList, cgroup.CgroupPath})
 	}
+
+	limits := bucket.NewResults("limits")
+	limits.SetHeaders([]string{"resource", "limit", "raw"})
+	for _, l := range readLimits() {
+		limits.AddContent([]interface{}{l.resource, l.value, l.raw})
+	}
+	res.AddSubResults(*limits)
 	return *res, nil
 }
 
+// readLimits reads the memory, CPU and PIDs limits at Root, the controllers
+// that are not enabled or mounted are skipped.
+func readLimits() []limit {
+	var limits []limit
+	if IsV2() {
+		if raw, err := readValue("memory.max"); err == nil {
+			limits = append(limits, limit{"memory", formatMemory(raw), raw})
+		}
+		if raw, err := readValue("cpu.max"); err == nil {
+			quota, period, _ := strings.Cut(raw, " ")
+			limits = append(limits, limit{"cpu", formatCPU(quota, period), raw})
+		}
+		if raw, err := readValue("pids.max"); err == nil {
+			limits = append(limits, limit{"pids", formatPids(raw), raw})
+		}
+		return limits
+	}
+
+	if raw, err := readValue("memory/memory.limit_in_bytes"); err == nil {
+		limits = append(limits, limit{"memory", formatMemory(raw), raw})
+	}
+	quota, errQuota := readValue("cpu/cpu.cfs_quota_us")
+	period, errPeriod := readValue("cpu/cpu.cfs_period_us")
+	if errQuota == nil && errPeriod == nil {
+		limits = append(limits, limit{"cpu", formatCPU(quota, period), quota + " " + period})
+	}
+	if raw, err := readValue("pids/pids.max"); err == nil {
+		limits = append(limits, limit{"pids", formatPids(raw), raw})
+	}
+	return limits
+}
+
+func readValue(file string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(Root, file))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// formatMemory formats bytes like Kubernetes, 512Mi for example. Under
+// cgroups v1, no limit is the max int64 value rounded down to the page size.
+func formatMemory(raw string) string {
+	if raw == unlimited {
+		return noLimit
+	}
+	bytes, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return raw
+	}
+	if bytes >= unlimitedV1Memory {
+		return noLimit
+	}
+	return resource.NewQuantity(bytes, resource.BinarySI).String()
+}
+
+// formatCPU formats the quota over the period in CPUs like Kubernetes, 500m
+// for example, no limit is "max" under cgroups v2 and -1 under v1.
+func formatCPU(quota string, period string) string {
+	if quota == unlimited || quota == "-1" {
+		return noLimit
+	}
+	q, errQuota := strconv.ParseInt(quota, 10, 64)
+	p, errPeriod := strconv.ParseInt(period, 10, 64)
+	if errQuota != nil || errPeriod != nil || p <= 0 {
+		return quota
+	}
+	return resource.NewMilliQuantity(q*1000/p, resource.DecimalSI).String()
+}
+
+func formatPids(raw string) string {
+	if raw == unlimited {
+		return noLimit
+	}
+	return raw
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
@@ -89,3 +198,36 @@ func readCgroupFile() ([]Cgroup, error) {
 
 	return lines, nil
 }
+
+// IsV2 returns true if the cgroup filesystem mounted at Root is the cgroups v2
+// unified hierarchy, the cgroup.controllers file only exists at its root.
+func IsV2() bool {
+	_, err := os.Stat(filepath.Join(Root, "cgroup.controllers"))
+	return err == nil
+}
+
+// ReadFlatKeyed parses cgroup files in the "key value" format, one pair per
+// line, like memory.events or memory.oom_control.
+func ReadFlatKeyed(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	values := map[string]string{}
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("format of %s file is incorrect, expected key value pairs", path)
+		}
+		values[fields[0]] = fields[1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
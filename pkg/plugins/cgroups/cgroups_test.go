@@ -0,0 +1,36 @@
+package cgroups
+
+import "testing"
+
+func TestFormatMemory(t *testing.T) {
+	tests := map[string]string{
+		"536870912":           "512Mi",
+		"1610612736":          "1536Mi",
+		"max":                 noLimit,
+		"9223372036854771712": noLimit,
+		"1024":                "1Ki",
+	}
+	for raw, want := range tests {
+		if got := formatMemory(raw); got != want {
+			t.Errorf("formatMemory(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+func TestFormatCPU(t *testing.T) {
+	tests := []struct {
+		quota  string
+		period string
+		want   string
+	}{
+		{"50000", "100000", "500m"},
+		{"200000", "100000", "2"},
+		{"max", "100000", noLimit},
+		{"-1", "100000", noLimit},
+	}
+	for _, tt := range tests {
+		if got := formatCPU(tt.quota, tt.period); got != tt.want {
+			t.Errorf("formatCPU(%q, %q) = %q, want %q", tt.quota, tt.period, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package cloudcreds
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/token"
+)
+
+const (
+	bucketName        = "cloudcreds"
+	bucketDescription = "CloudCreds looks for cloud provider credentials files and decodes the workload identity tokens claims."
+
+	providerAWS   = "AWS"
+	providerGCP   = "GCP"
+	providerAzure = "Azure"
+
+	typeStatic   = "static credentials"
+	typeConfig   = "configuration"
+	typeIdentity = "workload identity token"
+)
+
+var bucketAliases = []string{"cloudcred", "cloudcredentials"}
+
+type candidate struct {
+	path     string
+	provider string
+	kind     string
+}
+
+// fixedCandidates are the default locations of the credentials, the home
+// relative ones are added at runtime.
+var fixedCandidates = []candidate{
+	{"/var/run/secrets/eks.amazonaws.com/serviceaccount/token", providerAWS, typeIdentity},
+	{"/var/run/secrets/pods.eks.amazonaws.com/serviceaccount/eks-pod-identity-token", providerAWS, typeIdentity},
+	{"/var/run/secrets/azure/tokens/azure-identity-token", providerAzure, typeIdentity},
+	{"/etc/kubernetes/azure.json", providerAzure, typeStatic},
+}
+
+var homeCandidates = []candidate{
+	{".aws/credentials", providerAWS, typeStatic},
+	{".aws/config", providerAWS, typeConfig},
+	{".config/gcloud/application_default_credentials.json", providerGCP, typeStatic},
+	{".config/gcloud/credentials.db", providerGCP, typeStatic},
+	{".azure/msal_token_cache.json", providerAzure, typeStatic},
+	{".azure/accessTokens.json", providerAzure, typeStatic},
+}
+
+// envCandidates are env vars set by the cloud providers webhooks or by users
+// to point to the credentials.
+var envCandidates = []candidate{
+	{"AWS_WEB_IDENTITY_TOKEN_FILE", providerAWS, typeIdentity},
+	{"AWS_SHARED_CREDENTIALS_FILE", providerAWS, typeStatic},
+	{"AZURE_FEDERATED_TOKEN_FILE", providerAzure, typeIdentity},
+	{"GOOGLE_APPLICATION_CREDENTIALS", providerGCP, typeStatic},
+}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	candidates := append([]candidate{}, fixedCandidates...)
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, c := range homeCandidates {
+			candidates = append(candidates, candidate{filepath.Join(home, c.path), c.provider, c.kind})
+		}
+	}
+	for _, c := range envCandidates {
+		if path := os.Getenv(c.path); path != "" {
+			candidates = append(candidates, candidate{path, c.provider, c.kind})
+		}
+	}
+
+	res.SetHeaders([]string{"path", "provider", "type", "details"})
+	seen := map[string]bool{}
+	found := 0
+	for _, c := range candidates {
+		if seen[c.path] {
+			continue
+		}
+		seen[c.path] = true
+		if _, err := os.Stat(c.path); err != nil {
+			continue
+		}
+		found++
+		// contents are never printed, only the claims of identity tokens
+		// that are not secret by themselves
+		details := ""
+		if c.kind == typeIdentity {
+			details = tokenDetails(c.path)
+		}
+		res.AddContent([]interface{}{c.path, c.provider, c.kind, details})
+	}
+
+	if found > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("%d cloud credentials files were found, they might allow to pivot to the cloud provider APIs.", found))
+	} else {
+		res.AddComment("No cloud credentials file was found.")
+	}
+
+	return *res, nil
+}
+
+func tokenDetails(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err.Error()
+	}
+	claims, err := token.DecodeClaims(strings.TrimSpace(string(content)))
+	if err != nil {
+		return err.Error()
+	}
+	details := []string{fmt.Sprintf("aud=%v", claims.Audience)}
+	if claims.Expiry != 0 {
+		details = append(details, "exp="+claims.ExpiryString())
+	}
+	return strings.Join(details, " ")
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewCloudCredsBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewCloudCredsBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -1,8 +1,13 @@
 package cloudmetadata
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
@@ -38,24 +43,72 @@ var endpoints = map[string]string{
 	"OpenStack":    "http://169.254.169.254/openstack",
 }
 
-type Bucket struct{}
+type Bucket struct {
+	networkTimeout time.Duration
+}
 
-// wait for 100ms maximum, request should be quick
+// wait for 100ms maximum by default, request should be quick
 const networkTimeout = 100 * time.Millisecond
 
+const optionTimeout = bucketName + ".timeout"
+
+// identityTimeout bounds the requests retrieving the identity, they only
+// happen once a metadata service answered so they can afford to wait longer.
+const identityTimeout = time.Second
+
+// maxSnippetLength truncates the identity read from the metadata services.
+const maxSnippetLength = 256
+
+// identityProbe retrieves a description of the cloud identity from the
+// metadata service at base, it never returns credentials. The description is
+// empty if the service does not belong to the cloud, since some clouds share
+// the same address, and exposed is true if credentials are attached to the
+// identity.
+type identityProbe func(ctx context.Context, client *http.Client, base string) (identity string, exposed bool, err error)
+
+var identityProbes = map[string]struct {
+	base  string
+	probe identityProbe
+}{
+	"AWS":         {"http://169.254.169.254", awsIdentity},
+	"GoogleCloud": {"http://metadata.google.internal", gcpIdentity},
+	"Azure":       {"http://169.254.169.254", azureIdentity},
+}
+
 // This plugin is "slow" because it has a network timeout on scan
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
 
-	scanResult := scanEndpoints(endpoints)
+	scanResult := scanEndpoints(ctx, endpoints, n.networkTimeout)
+	sort.Slice(scanResult, func(i, j int) bool {
+		return scanResult[i].Platform < scanResult[j].Platform
+	})
 
-	res.SetHeaders([]string{"cloudProvider", "success", "url", "error"})
+	client := &http.Client{
+		Timeout: identityTimeout,
+	}
+	res.SetHeaders([]string{"cloudProvider", "success", "url", "identity", bucket.ErrorHeader})
 	for _, resp := range scanResult {
-		if resp.Error != nil {
-			res.AddContent([]interface{}{resp.Platform, resp.Success, resp.URL, resp.Error.Error()})
-		} else {
-			res.AddContent([]interface{}{resp.Platform, resp.Success, resp.URL, ""})
+		var identity string
+		// IMDSv2 and the other services requiring headers reject the scan
+		// requests, but any answer means that someone is listening
+		if p, ok := identityProbes[resp.Platform]; ok && resp.Answered {
+			var exposed bool
+			var err error
+			identity, exposed, err = p.probe(ctx, client, p.base)
+			if err != nil {
+				res.AddComment(fmt.Sprintf("Failed to retrieve the %s identity: %v.", resp.Platform, err))
+			}
+			if identity != "" {
+				resp.Success = true
+				res.RaiseSeverity(bucket.SeverityMedium)
+			}
+			if exposed {
+				res.RaiseSeverity(bucket.SeverityHigh)
+				res.AddComment(fmt.Sprintf("The %s metadata service exposes the cloud identity of the node, its credentials might be retrievable from the pod.", resp.Platform))
+			}
 		}
+		res.AddContentWithError([]interface{}{resp.Platform, resp.Success, resp.URL, identity}, resp.Error)
 	}
 
 	return *res, nil
@@ -65,19 +118,21 @@ type Response struct {
 	Platform string
 	URL      string
 	Success  bool
+	// Answered is true if the endpoint sent a response, whatever its status
+	Answered bool
 	Error    error
 }
 
-func scanEndpoints(endpoints map[string]string) []Response {
+func scanEndpoints(ctx context.Context, endpoints map[string]string, timeout time.Duration) []Response {
 	client := http.Client{
-		Timeout: networkTimeout,
+		Timeout: timeout,
 	}
 
 	chResponses := make(chan Response, len(endpoints))
 
 	for platform, url := range endpoints {
 		go func(ch chan Response, platform string, url string) {
-			req, err := http.NewRequest(http.MethodGet, url, nil)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 			if err != nil {
 				ch <- Response{
 					Platform: platform,
@@ -102,13 +157,14 @@ func scanEndpoints(endpoints map[string]string) []Response {
 				}
 				return
 			}
+			resp.Body.Close()
 
-			// defer resp.Body.Close()
 			if resp.StatusCode == http.StatusNotFound {
 				ch <- Response{
 					Platform: platform,
 					URL:      url,
 					Success:  false,
+					Answered: true,
 					Error:    errors.New("not found"),
 				}
 				return
@@ -117,6 +173,7 @@ func scanEndpoints(endpoints map[string]string) []Response {
 				Platform: platform,
 				URL:      url,
 				Success:  resp.StatusCode == http.StatusOK,
+				Answered: true,
 			}
 		}(chResponses, platform, url)
 	}
@@ -129,6 +186,89 @@ func scanEndpoints(endpoints map[string]string) []Response {
 	return results
 }
 
+// request sends a request with the headers and returns the status code and
+// the beginning of the body.
+func request(ctx context.Context, client *http.Client, method string, url string, headers map[string]string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSnippetLength))
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, strings.TrimSpace(string(body)), nil
+}
+
+// awsIdentity tries the IMDSv2 session token handshake first and falls back
+// to IMDSv1, then lists the IAM role attached to the instance without
+// fetching its credentials.
+func awsIdentity(ctx context.Context, client *http.Client, base string) (string, bool, error) {
+	version := "IMDSv1"
+	headers := map[string]string{}
+	status, token, err := request(ctx, client, http.MethodPut, base+"/latest/api/token", map[string]string{
+		"X-aws-ec2-metadata-token-ttl-seconds": "60",
+	})
+	if err == nil && status == http.StatusOK && token != "" {
+		version = "IMDSv2"
+		headers["X-aws-ec2-metadata-token"] = token
+	}
+
+	status, roles, err := request(ctx, client, http.MethodGet, base+"/latest/meta-data/iam/security-credentials/", headers)
+	if err != nil {
+		return "", false, err
+	}
+	switch status {
+	case http.StatusOK:
+		// the role names are separated by new lines
+		return fmt.Sprintf("%s, IAM role %s", version, strings.Join(strings.Fields(roles), ", ")), true, nil
+	case http.StatusUnauthorized:
+		return "", false, errors.New("IMDSv2 is required but the session token was denied")
+	case http.StatusNotFound:
+		// other clouds answer on the same address, make sure it's AWS
+		if version == "IMDSv1" {
+			status, _, err := request(ctx, client, http.MethodGet, base+"/latest/meta-data/instance-id", headers)
+			if err != nil || status != http.StatusOK {
+				return "", false, err
+			}
+		}
+		return version + ", no IAM role", false, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// gcpIdentity reads the email of the default service account of the instance.
+func gcpIdentity(ctx context.Context, client *http.Client, base string) (string, bool, error) {
+	status, email, err := request(ctx, client, http.MethodGet, base+"/computeMetadata/v1/instance/service-accounts/default/email", map[string]string{
+		"Metadata-Flavor": "Google",
+	})
+	if err != nil || status != http.StatusOK {
+		return "", false, err
+	}
+	return "service account " + email, true, nil
+}
+
+// azureIdentity reads the subscription of the instance from the instance
+// metadata service, a managed identity might be attached to it.
+func azureIdentity(ctx context.Context, client *http.Client, base string) (string, bool, error) {
+	status, subscription, err := request(ctx, client, http.MethodGet, base+"/metadata/instance/compute/subscriptionId?api-version=2021-02-01&format=text", map[string]string{
+		"Metadata": "true",
+	})
+	if err != nil || status != http.StatusOK {
+		return "", false, err
+	}
+	return "subscription " + subscription, false, nil
+}
+
 // Register registers a plugin
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
@@ -138,11 +278,21 @@ func Register(b *bucket.Buckets) {
 		Factory: func(config bucket.Config) (bucket.Interface, error) {
 			return NewCloudMetadataBucket(config)
 		},
-		SideEffects:   false,
+		SideEffects:   true,
 		RequireClient: false,
+		Options: map[string]string{
+			optionTimeout: "Duration after which an endpoint that did not answer is considered absent, 100ms by default.",
+		},
 	})
 }
 
-func NewCloudMetadataBucket(_ bucket.Config) (*Bucket, error) {
-	return &Bucket{}, nil
+func NewCloudMetadataBucket(config bucket.Config) (*Bucket, error) {
+	timeout, err := config.Options.Duration(optionTimeout, networkTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid option %s: the timeout must be positive", optionTimeout)
+	}
+	return &Bucket{networkTimeout: timeout}, nil
 }
@@ -0,0 +1,85 @@
+package cloudmetadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// imds emulates the AWS instance metadata service, requiring the session
+// token if v2Only is set.
+func imds(v2Only bool, role string) http.Handler {
+	const token = "session-token"
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /latest/api/token", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(token))
+	})
+	authorized := func(r *http.Request) bool {
+		return !v2Only || r.Header.Get("X-aws-ec2-metadata-token") == token
+	}
+	mux.HandleFunc("GET /latest/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case !authorized(r):
+			w.WriteHeader(http.StatusUnauthorized)
+		case role == "":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte(role))
+		}
+	})
+	return mux
+}
+
+func TestAWSIdentity(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.Handler
+		wantID      string
+		wantExposed bool
+	}{
+		{"IMDSv2", imds(true, "node-role"), "IMDSv2, IAM role node-role", true},
+		{"IMDSv2 without role", imds(true, ""), "IMDSv2, no IAM role", false},
+		{"IMDSv1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte("node-role\nother-role"))
+		}), "IMDSv1, IAM role node-role, other-role", true},
+		{"not AWS", http.NotFoundHandler(), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			id, exposed, err := awsIdentity(context.Background(), server.Client(), server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.wantID || exposed != tt.wantExposed {
+				t.Errorf("awsIdentity() = %q, %v, want %q, %v", id, exposed, tt.wantID, tt.wantExposed)
+			}
+		})
+	}
+}
+
+func TestGCPIdentity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("node@project.iam.gserviceaccount.com"))
+	}))
+	defer server.Close()
+
+	id, exposed, err := gcpIdentity(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "service account node@project.iam.gserviceaccount.com" || !exposed {
+		t.Errorf("gcpIdentity() = %q, %v", id, exposed)
+	}
+}
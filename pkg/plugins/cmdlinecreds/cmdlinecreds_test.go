@@ -0,0 +1,57 @@
+package cmdlinecreds
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindCredentials(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "flag with equal",
+			args: []string{"mysqld", "--password=hunter2"},
+			want: []string{"--password=****"},
+		},
+		{
+			name: "flag with separate value",
+			args: []string{"app", "--api-key", "abcdef", "--verbose"},
+			want: []string{"--api-key ****"},
+		},
+		{
+			name: "environment variable",
+			args: []string{"env", "DB_PASSWORD=hunter2", "run"},
+			want: []string{"DB_PASSWORD=****"},
+		},
+		{
+			name: "connection string",
+			args: []string{"worker", "postgres://admin:hunter2@db:5432/prod"},
+			want: []string{"postgres://admin:****@db:5432/prod"},
+		},
+		{
+			name: "no credentials",
+			args: []string{"nginx", "-g", "daemon off;", "--port=8080"},
+			want: nil,
+		},
+		{
+			name: "file flag",
+			args: []string{"kube-apiserver", "--tls-private-key-file=/etc/kubernetes/pki/apiserver.key"},
+			want: nil,
+		},
+		{
+			name: "empty value",
+			args: []string{"app", "--token="},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindCredentials(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindCredentials() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
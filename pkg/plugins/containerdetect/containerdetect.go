@@ -2,6 +2,7 @@ package containerdetect
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"syscall"
@@ -23,7 +24,7 @@ var bucketAliases = []string{"container", "cdetect"}
 
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
 	res.SetHeaders([]string{"hint", "result"})
 
@@ -0,0 +1,235 @@
+package controlplane
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	bucketName        = "controlplane"
+	bucketDescription = "ControlPlane analyses the pod tolerations and affinity to determine if it can be scheduled on control plane nodes."
+
+	controlPlaneLabel = "node-role.kubernetes.io/control-plane"
+	// deprecated since 1.20 but still used by older clusters
+	masterLabel = "node-role.kubernetes.io/master"
+)
+
+var bucketAliases = []string{"master", "cp"}
+
+// controlPlaneTaints are the taints usually set by kubeadm and others on
+// control plane nodes.
+var controlPlaneTaints = []v1.Taint{
+	{Key: controlPlaneLabel, Effect: v1.TaintEffectNoSchedule},
+	{Key: masterLabel, Effect: v1.TaintEffectNoSchedule},
+}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	pod, err := automaticontext.CurrentPod(ctx, n.config.Client, n.config.Namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	res.SetHeaders([]string{"key", "operator", "value", "effect", "toleratesControlPlane"})
+	for _, t := range pod.Spec.Tolerations {
+		res.AddContent([]interface{}{t.Key, t.Operator, t.Value, t.Effect, toleratesAny(t, controlPlaneTaints)})
+	}
+
+	toleratesControlPlane := ToleratesTaints(pod.Spec.Tolerations, controlPlaneTaints)
+
+	nodes, err := n.config.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		// without nodes, only the tolerations can be analysed
+		res.AddComment(fmt.Sprintf("Failed to list nodes, only the tolerations were analysed: %s", err))
+		if toleratesControlPlane {
+			res.RaiseSeverity(bucket.SeverityMedium)
+			res.AddComment("The pod tolerates the control plane taints, it might be scheduled on control plane nodes if its affinity allows it.")
+		} else {
+			res.AddComment("The pod does not tolerate the control plane taints, it can't be scheduled on control plane nodes.")
+		}
+		return *res, nil
+	}
+
+	var schedulable, controlPlanes []string
+	for _, node := range nodes.Items {
+		if !IsSchedulable(pod, &node) {
+			continue
+		}
+		schedulable = append(schedulable, node.Name)
+		if isControlPlane(&node) {
+			controlPlanes = append(controlPlanes, node.Name)
+		}
+	}
+
+	res.AddComment(fmt.Sprintf("The pod could be scheduled on %d out of %d nodes: %v.", len(schedulable), len(nodes.Items), schedulable))
+	if len(controlPlanes) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("The pod could be scheduled on control plane nodes: %v.", controlPlanes))
+	} else if toleratesControlPlane {
+		res.AddComment("The pod tolerates the control plane taints but no control plane node matches its affinity.")
+	} else {
+		res.AddComment("The pod can't be scheduled on control plane nodes.")
+	}
+
+	return *res, nil
+}
+
+func isControlPlane(node *v1.Node) bool {
+	_, cp := node.Labels[controlPlaneLabel]
+	_, master := node.Labels[masterLabel]
+	return cp || master
+}
+
+func toleratesAny(t v1.Toleration, taints []v1.Taint) bool {
+	for i := range taints {
+		if t.ToleratesTaint(&taints[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToleratesTaints returns true if at least one of the taints is tolerated.
+func ToleratesTaints(tolerations []v1.Toleration, taints []v1.Taint) bool {
+	for _, t := range tolerations {
+		if toleratesAny(t, taints) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSchedulable is a simplified version of the scheduler filters: it checks
+// that all the NoSchedule and NoExecute taints of the node are tolerated and
+// that the node matches the node selector and required node affinity.
+func IsSchedulable(pod *v1.Pod, node *v1.Node) bool {
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for _, t := range pod.Spec.Tolerations {
+			if t.ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+
+	for key, value := range pod.Spec.NodeSelector {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	// terms are ORed
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if MatchesNodeSelectorTerm(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesNodeSelectorTerm returns true if all the requirements of the term
+// are satisfied by the node.
+func MatchesNodeSelectorTerm(term v1.NodeSelectorTerm, node *v1.Node) bool {
+	// an empty term matches no objects
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		value, found := node.Labels[req.Key]
+		if !matchesRequirement(req, value, found) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		// metadata.name is the only supported field
+		if !matchesRequirement(req, node.Name, req.Key == "metadata.name") {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesRequirement(req v1.NodeSelectorRequirement, value string, found bool) bool {
+	switch req.Operator {
+	case v1.NodeSelectorOpIn:
+		return found && contains(req.Values, value)
+	case v1.NodeSelectorOpNotIn:
+		return !found || !contains(req.Values, value)
+	case v1.NodeSelectorOpExists:
+		return found
+	case v1.NodeSelectorOpDoesNotExist:
+		return !found
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if !found || len(req.Values) != 1 {
+			return false
+		}
+		labelValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		reqValue, err := strconv.ParseInt(req.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if req.Operator == v1.NodeSelectorOpGt {
+			return labelValue > reqValue
+		}
+		return labelValue < reqValue
+	default:
+		return false
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewControlPlaneBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewControlPlaneBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -1,43 +1,95 @@
 package devices
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
-	"time"
+	"path/filepath"
+	"syscall"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
+	"golang.org/x/sys/unix"
 )
 
 const (
 	bucketName        = "devices"
-	bucketDescription = "Devices shows the list of devices available in the container."
+	bucketDescription = "Devices shows the list of devices available in the container and flags the dangerous ones."
 )
 
 var bucketAliases = []string{"device", "dev"}
 
+// dangerousDevices are the patterns of the device nodes giving access to the
+// memory, the disks or the hypervisor of the host, every block device is
+// considered dangerous as well.
+var dangerousDevices = []string{
+	"/dev/mem",
+	"/dev/kmem",
+	"/dev/port",
+	"/dev/kvm",
+	"/dev/sd*",
+	"/dev/nvme*",
+	"/dev/vd*",
+	"/dev/xvd*",
+	"/dev/dm-*",
+	"/dev/mapper/*",
+	"/dev/cpu/*/msr",
+}
+
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
-	// executes here the code of your plugin
+// device is a character or block device node.
+type device struct {
+	path  string
+	block bool
+	major uint32
+	minor uint32
+	mode  os.FileMode
+}
+
+func (d device) kind() string {
+	if d.block {
+		return "block"
+	}
+	return "char"
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	devs, err := readDev()
 	if err != nil {
 		return bucket.Results{}, err
 	}
 
 	res := bucket.NewResults(bucketName)
-	res.SetHeaders([]string{"mode", "isDir", "modTime", "name"})
-	for _, devDir := range devs {
-		dev, err := devDir.Info()
-		if err != nil {
-			// file was removed or renamed, ignore this edge case
-			continue
+	res.SetHeaders([]string{"path", "type", "major", "minor", "mode", "dangerous"})
+	dangerous := 0
+	for _, d := range devs {
+		isDangerous := isDangerous(d)
+		if isDangerous {
+			dangerous++
 		}
-		res.AddContent([]interface{}{dev.Mode().String(), dev.IsDir(), dev.ModTime().Format(time.RFC3339), dev.Name()})
+		res.AddContent([]interface{}{d.path, d.kind(), d.major, d.minor, d.mode.String(), isDangerous})
 	}
 	res.AddComment(fmt.Sprintf("%d devices are available.", len(devs)))
+	if dangerous > 0 {
+		res.AddComment(fmt.Sprintf("%d devices are dangerous, they might give access to the memory, the disks or the hypervisor of the host.", dangerous))
+		res.RaiseSeverity(bucket.SeverityHigh)
+	}
 	return *res, nil
 }
 
+func isDangerous(d device) bool {
+	if d.block {
+		return true
+	}
+	for _, pattern := range dangerousDevices {
+		if match, _ := filepath.Match(pattern, d.path); match {
+			return true
+		}
+	}
+	return false
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
@@ -55,10 +107,36 @@ func NewDevicesBucket(_ bucket.Config) (*Bucket, error) {
 	return &Bucket{}, nil
 }
 
-func readDev() ([]os.DirEntry, error) {
-	files, err := os.ReadDir("/dev")
-	if err != nil {
-		return nil, err
-	}
-	return files, nil
+// readDev walks /dev for the device nodes, symlinks are not followed.
+func readDev() ([]device, error) {
+	var devs []device
+	err := filepath.WalkDir("/dev", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// subdirectories might not be readable, ignore them
+			if path != "/dev" {
+				return nil
+			}
+			return err
+		}
+		if entry.Type()&fs.ModeDevice == 0 {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// file was removed or renamed, ignore this edge case
+			return nil
+		}
+		d := device{
+			path:  path,
+			block: info.Mode()&fs.ModeCharDevice == 0,
+			mode:  info.Mode(),
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			d.major = unix.Major(uint64(stat.Rdev))
+			d.minor = unix.Minor(uint64(stat.Rdev))
+		}
+		devs = append(devs, d)
+		return nil
+	})
+	return devs, err
 }
@@ -0,0 +1,151 @@
+package dnsconfig
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	bucketName        = "dnsconfig"
+	bucketDescription = "DNSConfig reports the dnsPolicy and dnsConfig of the pod and flags nameservers bypassing the cluster DNS."
+
+	resolvConfPath = "/etc/resolv.conf"
+
+	sourceSpec       = "spec"
+	sourceResolvConf = "resolv.conf"
+)
+
+var bucketAliases = []string{"dnspolicy", "resolvconf"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+type resolvConf struct {
+	nameservers []string
+	searches    []string
+	options     []string
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	pod, err := automaticontext.CurrentPod(ctx, n.config.Client, n.config.Namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	policy := pod.Spec.DNSPolicy
+	var specConf resolvConf
+	if pod.Spec.DNSConfig != nil {
+		specConf.nameservers = pod.Spec.DNSConfig.Nameservers
+		specConf.searches = pod.Spec.DNSConfig.Searches
+		for _, o := range pod.Spec.DNSConfig.Options {
+			option := o.Name
+			if o.Value != nil {
+				option += ":" + *o.Value
+			}
+			specConf.options = append(specConf.options, option)
+		}
+	}
+
+	res.SetHeaders([]string{"source", "dnsPolicy", "nameservers", "searches", "options"})
+	res.AddContent([]interface{}{sourceSpec, policy, specConf.nameservers, specConf.searches, specConf.options})
+
+	fileConf, err := readResolvConf()
+	if err != nil {
+		res.AddComment(fmt.Sprintf("Failed to read %s: %s", resolvConfPath, err))
+	} else {
+		res.AddContent([]interface{}{sourceResolvConf, "", fileConf.nameservers, fileConf.searches, fileConf.options})
+	}
+
+	switch policy {
+	case v1.DNSClusterFirst, v1.DNSClusterFirstWithHostNet:
+		// the cluster DNS is used, custom nameservers are appended to it
+	case v1.DNSNone:
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment("The dnsPolicy is None, the pod DNS configuration is entirely defined by its dnsConfig and bypasses the cluster DNS.")
+	case v1.DNSDefault:
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("The dnsPolicy is Default, the pod inherits the DNS configuration of the node and bypasses the cluster DNS.")
+	}
+
+	// the cluster DNS service is not always readable, in that case the
+	// custom nameservers are all considered external
+	clusterDNS := map[string]bool{}
+	svc, err := n.config.Client.CoreV1().Services("kube-system").Get(ctx, "kube-dns", metav1.GetOptions{})
+	if err == nil {
+		for _, ip := range svc.Spec.ClusterIPs {
+			clusterDNS[ip] = true
+		}
+	}
+	var external []string
+	for _, ns := range specConf.nameservers {
+		if !clusterDNS[ns] {
+			external = append(external, ns)
+		}
+	}
+	if len(external) > 0 {
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("The pod uses custom nameservers outside of the cluster DNS, they could be used to hijack resolutions or exfiltrate data: %v.", external))
+	}
+
+	return *res, nil
+}
+
+func readResolvConf() (resolvConf, error) {
+	var conf resolvConf
+	file, err := os.Open(resolvConfPath)
+	if err != nil {
+		return conf, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			conf.nameservers = append(conf.nameservers, fields[1])
+		case "search":
+			conf.searches = append(conf.searches, fields[1:]...)
+		case "options":
+			conf.options = append(conf.options, fields[1:]...)
+		}
+	}
+
+	return conf, scanner.Err()
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewDNSConfigBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewDNSConfigBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package environment
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -19,7 +20,7 @@ var bucketAliases = []string{"environments", "environ", "env"}
 
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
 	res.SetHeaders([]string{"name", "value"})
 	for name, value := range kubeEnviron() {
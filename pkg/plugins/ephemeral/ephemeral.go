@@ -0,0 +1,79 @@
+package ephemeral
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/authorization"
+	v1 "k8s.io/api/authorization/v1"
+)
+
+const (
+	bucketName        = "ephemeral"
+	bucketDescription = "Ephemeral checks if the token can add ephemeral debug containers to pods, for example with kubectl debug."
+
+	subresource = "ephemeralcontainers"
+)
+
+var bucketAliases = []string{"ephemeralcontainers", "debug"}
+
+// kubectl debug patches the ephemeralcontainers subresource, update is
+// equivalent for this purpose
+var verbs = []string{"patch", "update"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+	res.AddComment(fmt.Sprintf("Checking ephemeral containers permissions in the %q namespace.", n.config.Namespace))
+
+	res.SetHeaders([]string{"resource", "verb", "allowed", "reason"})
+	var allowedVerbs []string
+	for _, verb := range verbs {
+		allowed, reason, err := authorization.CanI(ctx, n.config.Client, v1.ResourceAttributes{
+			Namespace:   n.config.Namespace,
+			Verb:        verb,
+			Resource:    "pods",
+			Subresource: subresource,
+		})
+		if err != nil {
+			return bucket.Results{}, err
+		}
+		if allowed {
+			allowedVerbs = append(allowedVerbs, verb)
+		}
+		res.AddContent([]interface{}{"pods/" + subresource, verb, allowed, reason})
+	}
+
+	if len(allowedVerbs) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("The token can %v pods/%s, a privileged debug container could be attached to any pod of the namespace.", allowedVerbs, subresource))
+	}
+
+	return *res, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewEphemeralBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewEphemeralBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package exposure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/authorization"
+	v1 "k8s.io/api/authorization/v1"
+)
+
+const (
+	bucketName        = "exposure"
+	bucketDescription = "Exposure checks if the token can create or modify services, which allows to expose workloads with LoadBalancer or NodePort services."
+)
+
+var bucketAliases = []string{"expose", "loadbalancer", "nodeport"}
+
+// RBAC does not distinguish the type of the services, any of these verbs
+// allows to create or switch a service to the LoadBalancer or NodePort types
+var verbs = []struct {
+	verb        string
+	implication string
+}{
+	{"create", "can create LoadBalancer and NodePort services"},
+	{"patch", "can switch existing services to LoadBalancer or NodePort"},
+	{"update", "can switch existing services to LoadBalancer or NodePort"},
+}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+	res.AddComment(fmt.Sprintf("Checking services permissions in the %q namespace.", n.config.Namespace))
+
+	res.SetHeaders([]string{"verb", "allowed", "implication", "reason"})
+	var allowedVerbs []string
+	for _, v := range verbs {
+		allowed, reason, err := authorization.CanI(ctx, n.config.Client, v1.ResourceAttributes{
+			Namespace: n.config.Namespace,
+			Verb:      v.verb,
+			Resource:  "services",
+		})
+		if err != nil {
+			return bucket.Results{}, err
+		}
+		implication := ""
+		if allowed {
+			allowedVerbs = append(allowedVerbs, v.verb)
+			implication = v.implication
+		}
+		res.AddContent([]interface{}{v.verb, allowed, implication, reason})
+	}
+
+	if len(allowedVerbs) > 0 {
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("The token can %v services, RBAC does not restrict the service type so internal workloads could be exposed externally or on the node ports.", allowedVerbs))
+	}
+
+	return *res, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewExposureBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewExposureBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
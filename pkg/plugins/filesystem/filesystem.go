@@ -0,0 +1,161 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "filesystem"
+	bucketDescription = "Filesystem audits the permissions of sensitive paths like secrets, runtime sockets and host mounts."
+
+	optionPaths = bucketName + ".paths"
+)
+
+var bucketAliases = []string{"fs", "permissions", "perms"}
+
+// sensitivePath is a path to audit, the read severity is raised if the path
+// can be read, secrets of the node being more critical than the ones of the
+// container image. A host path only exists in the container if it comes from
+// the node.
+type sensitivePath struct {
+	path         string
+	readSeverity bucket.Severity
+	host         bool
+}
+
+// curatedPaths are the paths whose access gives secrets or an escalation, all
+// of them are high when writable and the node credentials critical.
+var curatedPaths = []sensitivePath{
+	// accounts of the container, or of the host if its filesystem is mounted
+	{"/etc/shadow", bucket.SeverityMedium, false},
+	{"/etc/gshadow", bucket.SeverityMedium, false},
+	{"/etc/passwd", bucket.SeverityNone, false},
+	{"/etc/sudoers", bucket.SeverityNone, false},
+	{"/root/.ssh", bucket.SeverityMedium, false},
+	{"/root/.ssh/authorized_keys", bucket.SeverityNone, false},
+	{"/root/.ssh/id_rsa", bucket.SeverityMedium, false},
+	{"/root/.ssh/id_ed25519", bucket.SeverityMedium, false},
+	// credentials of the node components
+	{"/var/lib/kubelet/kubeconfig", bucket.SeverityHigh, true},
+	{"/var/lib/kubelet/pki", bucket.SeverityHigh, true},
+	{"/etc/kubernetes/kubelet.conf", bucket.SeverityHigh, true},
+	{"/etc/kubernetes/admin.conf", bucket.SeverityHigh, true},
+	{"/etc/kubernetes/pki", bucket.SeverityHigh, true},
+	// connecting to the container runtime sockets requires the write access
+	{"/var/run/docker.sock", bucket.SeverityNone, false},
+	{"/run/containerd/containerd.sock", bucket.SeverityNone, false},
+	{"/var/run/crio/crio.sock", bucket.SeverityNone, false},
+	// usual mount points of the host filesystem
+	{"/host", bucket.SeverityNone, false},
+	{"/rootfs", bucket.SeverityNone, false},
+}
+
+type Bucket struct {
+	paths []sensitivePath
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	// root can read and write all the files of the image, only the paths
+	// coming from the node are findings
+	root := os.Geteuid() == 0
+	var rootDev uint64
+	if stat, err := os.Stat("/"); err == nil {
+		if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+			rootDev = uint64(sys.Dev)
+		}
+	}
+
+	res.SetHeaders([]string{"path", "exists", "mode", "owner", "readable", "writable"})
+	var readable, writable, worldWritable, skipped []string
+	for _, p := range n.paths {
+		info, err := os.Stat(p.path)
+		if err != nil {
+			res.AddContent([]interface{}{p.path, false, "", "", false, false})
+			continue
+		}
+		var owner string
+		// without the device, the path is considered as coming from the node
+		foreign := true
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			owner = fmt.Sprintf("%d:%d", stat.Uid, stat.Gid)
+			foreign = uint64(stat.Dev) != rootDev
+		}
+		// access checks the permissions of the process without opening the
+		// files, it accounts for the read-only mounts on write
+		canRead := unix.Access(p.path, unix.R_OK) == nil
+		canWrite := unix.Access(p.path, unix.W_OK) == nil
+		res.AddContent([]interface{}{p.path, true, info.Mode().String(), owner, canRead, canWrite})
+
+		if root && !p.host && !foreign {
+			if canRead || canWrite {
+				skipped = append(skipped, p.path)
+			}
+		} else {
+			if canRead && p.readSeverity != bucket.SeverityNone {
+				res.RaiseSeverity(p.readSeverity)
+				readable = append(readable, p.path)
+			}
+			if canWrite {
+				if p.host {
+					res.RaiseSeverity(bucket.SeverityCritical)
+				} else {
+					res.RaiseSeverity(bucket.SeverityHigh)
+				}
+				writable = append(writable, p.path)
+			}
+		}
+		// the sticky bit of directories like /tmp prevents the deletion of
+		// the files of the other users
+		if info.Mode().Perm()&0o002 != 0 && !(info.IsDir() && info.Mode()&os.ModeSticky != 0) {
+			res.RaiseSeverity(bucket.SeverityHigh)
+			worldWritable = append(worldWritable, p.path)
+		}
+	}
+
+	if len(readable) > 0 {
+		res.AddComment(fmt.Sprintf("Secrets are readable: %v.", readable))
+	}
+	if len(writable) > 0 {
+		res.AddComment(fmt.Sprintf("Sensitive paths are writable, they might allow to escalate privileges or to escape: %v.", writable))
+	}
+	if len(worldWritable) > 0 {
+		res.AddComment(fmt.Sprintf("Sensitive paths are world-writable, any user can modify them: %v.", worldWritable))
+	}
+	if len(skipped) > 0 {
+		res.AddComment(fmt.Sprintf("You are root, the paths of the container image were not reported: %v.", skipped))
+	}
+
+	return *res, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewFilesystemBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+		Options: map[string]string{
+			optionPaths: "Comma separated list of additional paths to audit, considered secrets when readable.",
+		},
+	})
+}
+
+func NewFilesystemBucket(config bucket.Config) (*Bucket, error) {
+	paths := append([]sensitivePath{}, curatedPaths...)
+	for _, path := range config.Options.StringSlice(optionPaths, nil) {
+		paths = append(paths, sensitivePath{path, bucket.SeverityMedium, false})
+	}
+	return &Bucket{paths: paths}, nil
+}
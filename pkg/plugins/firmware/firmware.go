@@ -0,0 +1,128 @@
+package firmware
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "firmware"
+	bucketDescription = "Firmware checks the access to the EFI variables and DMI information of the host in /sys."
+)
+
+var bucketAliases = []string{"efi", "dmi"}
+
+type firmwarePath struct {
+	path string
+	// critical is set for the EFI variables, writing them can brick the
+	// hardware, the DMI entries only leak information
+	critical bool
+}
+
+var firmwarePaths = []firmwarePath{
+	{"/sys/firmware/efi/efivars", true},
+	{"/sys/firmware/efi/vars", true},
+	{"/sys/firmware/dmi/tables/DMI", false},
+	{"/sys/class/dmi/id/product_serial", false},
+	{"/sys/class/dmi/id/product_uuid", false},
+	{"/sys/class/dmi/id/board_serial", false},
+	{"/sys/class/dmi/id/chassis_serial", false},
+}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+	res.SetHeaders([]string{"path", "present", "readable", "writable"})
+
+	var readable, writableEFI, writableDMI []string
+	for _, p := range firmwarePaths {
+		info, err := os.Stat(p.path)
+		if err != nil {
+			res.AddContent([]interface{}{p.path, false, false, false})
+			continue
+		}
+		r := canRead(p.path, info.IsDir())
+		w := canWrite(p.path, info.IsDir())
+		res.AddContent([]interface{}{p.path, true, r, w})
+
+		if r {
+			readable = append(readable, p.path)
+		}
+		if w {
+			if p.critical {
+				writableEFI = append(writableEFI, p.path)
+				res.RaiseSeverity(bucket.SeverityCritical)
+			} else {
+				writableDMI = append(writableDMI, p.path)
+				res.RaiseSeverity(bucket.SeverityMedium)
+			}
+		} else if r && !p.critical {
+			res.RaiseSeverity(bucket.SeverityLow)
+		}
+	}
+
+	if len(writableEFI) > 0 {
+		res.AddComment(fmt.Sprintf("EFI variables are writable, writing them can brick the host: %v.", writableEFI))
+	}
+	if len(writableDMI) > 0 {
+		res.AddComment(fmt.Sprintf("DMI paths are writable: %v.", writableDMI))
+	}
+	if len(readable) > 0 {
+		res.AddComment(fmt.Sprintf("Firmware paths are readable, DMI information leaks the host serial numbers: %v.", readable))
+	}
+
+	return *res, nil
+}
+
+// canRead lists the first entry of directories and reads a single byte of
+// files.
+func canRead(path string, isDir bool) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	if isDir {
+		_, err = file.Readdirnames(1)
+		return err == nil
+	}
+	_, err = file.Read(make([]byte, 1))
+	return err == nil
+}
+
+// canWrite opens files with O_WRONLY without writing anything, access would
+// succeed for root even on the read-only sysfs attributes. Directories can't be
+// opened for writing, access fails for them on read-only mounts.
+func canWrite(path string, isDir bool) bool {
+	if isDir {
+		return unix.Access(path, unix.W_OK) == nil
+	}
+	fd, err := unix.Open(path, unix.O_WRONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return false
+	}
+	unix.Close(fd)
+	return true
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewFirmwareBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewFirmwareBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,182 @@
+package hostaliases
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "hostaliases"
+	bucketDescription = "HostAliases reports the entries of /etc/hosts and the hostAliases of the pod, flagging the ones shadowing cluster names."
+
+	hostsPath = "/etc/hosts"
+	// the kubelet writes this line before the entries coming from the
+	// hostAliases field of the pod spec
+	hostAliasesMarker = "# Entries added by HostAliases."
+
+	sourceSpec    = "spec"
+	sourceFile    = "file"
+	sourceKubelet = "kubelet"
+)
+
+var bucketAliases = []string{"hosts", "etchosts"}
+
+// shadowedSuffixes are domain names that should be resolved by the cluster
+// DNS or by the cloud provider and not by a static entry.
+var shadowedSuffixes = []string{".svc", ".cluster.local", ".internal"}
+
+var shadowedNames = []string{"kubernetes", "kubernetes.default"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+type hostEntry struct {
+	ip       string
+	hostname string
+	source   string
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	entries, err := readHosts()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	// the kubelet writes the pod IP with its hostname and FQDN, which ends
+	// with .svc.cluster.local for pods with a subdomain
+	ownIPs := map[string]bool{}
+	if hostname, err := os.Hostname(); err == nil {
+		for _, e := range entries {
+			if e.hostname == hostname {
+				ownIPs[e.ip] = true
+			}
+		}
+	}
+
+	// the pod spec is the source of truth for hostAliases, but the /etc/hosts
+	// content is still valuable if it can't be retrieved
+	client, err := n.config.OptionalClient()
+	if err != nil {
+		res.AddComment(fmt.Sprintf("No client could be loaded, sources were guessed from %s: %s", hostsPath, err))
+	} else if pod, err := automaticontext.CurrentPod(ctx, client, n.config.Namespace); err != nil {
+		res.AddComment(fmt.Sprintf("Failed to retrieve the pod spec, sources were guessed from %s: %s", hostsPath, err))
+	} else {
+		for _, ip := range pod.Status.PodIPs {
+			ownIPs[ip.IP] = true
+		}
+		specAliases := map[string]string{}
+		for _, alias := range pod.Spec.HostAliases {
+			for _, hostname := range alias.Hostnames {
+				specAliases[hostname] = alias.IP
+			}
+		}
+		for i := range entries {
+			if ip, found := specAliases[entries[i].hostname]; found && ip == entries[i].ip {
+				entries[i].source = sourceSpec
+			} else {
+				entries[i].source = sourceFile
+			}
+		}
+	}
+	for i := range entries {
+		if entries[i].source == sourceFile && ownIPs[entries[i].ip] {
+			entries[i].source = sourceKubelet
+		}
+	}
+
+	res.SetHeaders([]string{"ip", "hostname", "source", "suspicious"})
+	suspicious := 0
+	for _, e := range entries {
+		// the entries of the pod itself are managed by the kubelet
+		s := e.source != sourceKubelet && isShadowing(e.hostname)
+		if s {
+			suspicious++
+		}
+		res.AddContent([]interface{}{e.ip, e.hostname, e.source, s})
+	}
+
+	if suspicious > 0 {
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("%d entries shadow cluster or cloud names and might redirect traffic.", suspicious))
+	}
+
+	return *res, nil
+}
+
+func readHosts() ([]hostEntry, error) {
+	file, err := os.Open(hostsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []hostEntry
+	source := sourceFile
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == hostAliasesMarker {
+			source = sourceSpec
+			continue
+		}
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, hostname := range fields[1:] {
+			entries = append(entries, hostEntry{ip: fields[0], hostname: hostname, source: source})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func isShadowing(hostname string) bool {
+	hostname = strings.TrimSuffix(strings.ToLower(hostname), ".")
+	for _, name := range shadowedNames {
+		if hostname == name {
+			return true
+		}
+	}
+	for _, suffix := range shadowedSuffixes {
+		if strings.HasSuffix(hostname, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewHostAliasesBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewHostAliasesBucket(config bucket.Config) (*Bucket, error) {
+	return &Bucket{
+		config: config,
+	}, nil
+}
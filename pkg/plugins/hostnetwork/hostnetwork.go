@@ -0,0 +1,206 @@
+package hostnetwork
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "hostnetwork"
+	bucketDescription = "HostNetwork uses the interfaces and the routing table to estimate if the container shares the host network namespace."
+
+	routePath = "/proc/net/route"
+
+	// MaxPodInterfaces is the number of non-loopback interfaces tolerated in
+	// a pod, a pod usually has one but multi-network CNIs like Multus add a
+	// second one
+	MaxPodInterfaces = 2
+)
+
+var bucketAliases = []string{"hostnet", "hn"}
+
+// nodeInterfacePrefixes are prefixes of interfaces usually only found on the
+// node side: container bridges, veth peers and CNI specific devices.
+var nodeInterfacePrefixes = []string{"docker", "cni", "cbr", "veth", "cali", "flannel", "cilium_", "vxlan", "tunl", "weave", "kube-", "virbr", "br-"}
+
+type Bucket struct{}
+
+// Route is an entry of the IPv4 routing table.
+type Route struct {
+	Interface   string
+	Destination net.IP
+	Gateway     net.IP
+	Mask        net.IPMask
+}
+
+// IsDefault returns true if the route is a default route.
+func (r Route) IsDefault() bool {
+	ones, _ := r.Mask.Size()
+	return r.Destination.Equal(net.IPv4zero) && ones == 0
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	routes, err := Routes()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	defaultRoutes := 0
+	for _, r := range routes {
+		if r.IsDefault() {
+			defaultRoutes++
+		}
+	}
+
+	var names, mtus []string
+	var nodeInterfaces []string
+	for _, i := range interfaces {
+		// down interfaces are not relevant, some are created by default in
+		// every network namespace
+		if i.Flags&net.FlagLoopback != 0 || i.Flags&net.FlagUp == 0 {
+			continue
+		}
+		names = append(names, i.Name)
+		mtus = append(mtus, fmt.Sprintf("%s:%d", i.Name, i.MTU))
+		if IsNodeInterface(i.Name) {
+			nodeInterfaces = append(nodeInterfaces, i.Name)
+		}
+	}
+
+	// a pod network namespace usually has a single interface, a default
+	// route via the CNI gateway and a route for its subnet
+	score := 0
+	if len(nodeInterfaces) > 0 {
+		score += 2
+		res.AddComment(fmt.Sprintf("Node side interfaces are visible: %v.", nodeInterfaces))
+	}
+	if len(names) > MaxPodInterfaces {
+		score++
+		res.AddComment(fmt.Sprintf("%d non-loopback interfaces are up, a pod usually has one and at most %d with a multi-network CNI.", len(names), MaxPodInterfaces))
+	}
+	if len(routes) > 3 || defaultRoutes > 1 {
+		score++
+		res.AddComment(fmt.Sprintf("The routing table has %d routes with %d default routes, a pod usually has a single default route.", len(routes), defaultRoutes))
+	}
+
+	var likelihood string
+	switch {
+	case score >= 3:
+		likelihood = "likely"
+		res.RaiseSeverity(bucket.SeverityHigh)
+	case score > 0:
+		likelihood = "possible"
+		res.RaiseSeverity(bucket.SeverityLow)
+	default:
+		likelihood = "unlikely"
+	}
+
+	res.SetHeaders([]string{"routes", "defaultRoutes", "upInterfaces", "mtus", "hostNetworkLikelihood"})
+	res.AddContent([]interface{}{len(routes), defaultRoutes, len(names), mtus, likelihood})
+	res.AddComment("These are heuristics, they should be corroborated with other buckets like pidnamespace.")
+
+	return *res, nil
+}
+
+// IsNodeInterface returns true if the interface name is the one of a device
+// usually only found on the node side.
+func IsNodeInterface(name string) bool {
+	for _, prefix := range nodeInterfacePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Routes parses the IPv4 routing table of the network namespace in
+// /proc/net/route, addresses are in hexadecimal in host byte order.
+func Routes() ([]Route, error) {
+	file, err := os.Open(routePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var routes []Route
+	scanner := bufio.NewScanner(file)
+	// skip the header line
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("format of %s file is incorrect, missing fields", routePath)
+		}
+		destination, err := parseHexIP(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		gateway, err := parseHexIP(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseHexIP(fields[7])
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, Route{
+			Interface:   fields[0],
+			Destination: destination,
+			Gateway:     gateway,
+			Mask:        net.IPMask(mask.To4()),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+func parseHexIP(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != net.IPv4len {
+		return nil, fmt.Errorf("format of %s file is incorrect, invalid address %q", routePath, s)
+	}
+	// the kernel prints the raw in memory value, that is little endian on
+	// all the architectures kdigger supports
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(b))
+	return ip, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewHostNetworkBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewHostNetworkBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,98 @@
+package hostpath
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/mount"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "hostpath"
+	bucketDescription = "HostPath lists the hostPath volumes of the pod and checks if their mounts are present and writable in the container."
+)
+
+var bucketAliases = []string{"hostpaths", "hp"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	pod, err := automaticontext.CurrentPod(ctx, n.config.Client, n.config.Namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	mnts, err := mount.Mounts()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	mounted := map[string]mount.Mount{}
+	for _, m := range mnts {
+		mounted[m.Path] = m
+	}
+
+	hostPaths := map[string]string{}
+	for _, v := range pod.Spec.Volumes {
+		if v.HostPath != nil {
+			hostPaths[v.Name] = v.HostPath.Path
+		}
+	}
+
+	res.SetHeaders([]string{"volume", "hostPath", "mountPath", "readOnly", "mounted", "writable"})
+	var writables []string
+	// the mounts of the other containers of the pod are reported as not
+	// mounted since they are not visible from this container
+	for _, c := range pod.Spec.Containers {
+		for _, vm := range c.VolumeMounts {
+			hostPath, found := hostPaths[vm.Name]
+			if !found {
+				continue
+			}
+			m, isMounted := mounted[vm.MountPath]
+			writable := isMounted && m.ReadWrite() && unix.Access(vm.MountPath, unix.W_OK) == nil
+			if writable {
+				writables = append(writables, hostPath)
+			}
+			res.AddContent([]interface{}{vm.Name, hostPath, vm.MountPath, vm.ReadOnly, isMounted, writable})
+		}
+	}
+
+	if len(hostPaths) == 0 {
+		res.AddComment("The pod has no hostPath volume.")
+	}
+	if len(writables) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("Host paths are mounted writable, they can be used for persistence on the node or to escape the container: %v.", writables))
+	}
+
+	return *res, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewHostPathBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewHostPathBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
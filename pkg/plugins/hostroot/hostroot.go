@@ -0,0 +1,163 @@
+package hostroot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-ps"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/pidnamespace"
+)
+
+const (
+	bucketName        = "hostroot"
+	bucketDescription = "HostRoot checks if the host filesystem is reachable via /proc/1/root when PID 1 is the host init."
+
+	initRoot   = "/proc/1/root"
+	initCgroup = "/proc/1/cgroup"
+)
+
+var bucketAliases = []string{"hostfs", "proc1"}
+
+// containerCgroups are found in the cgroup paths of the containers, while the
+// host init lives at the root of the hierarchy or in init.scope.
+var containerCgroups = []string{"kubepods", "docker", "containerd", "crio", "libpod"}
+
+// hostSecrets are files only readable by root on the host, being able to read
+// them confirms a full access to the host filesystem.
+var hostSecrets = []string{
+	"/etc/shadow",
+	"/etc/kubernetes/admin.conf",
+	"/etc/kubernetes/pki/ca.key",
+	"/var/lib/kubelet/kubeconfig",
+	"/var/lib/kubelet/pki/kubelet-client-current.pem",
+}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	selfNS, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to read own mount namespace: %w", err)
+	}
+	initNS, err := os.Readlink("/proc/1/ns/mnt")
+	if err == nil && initNS == selfNS {
+		res.AddComment("PID 1 shares the mount namespace of the container, it is not the host init, the PID namespace does not seem to be shared.")
+		return *res, nil
+	}
+
+	initName := ""
+	if p, err := ps.FindProcess(1); err == nil && p != nil {
+		initName = p.Executable()
+	}
+
+	// the cgroup of PID 1 can't always be read, the name is then the only hint
+	cgroup, _ := os.ReadFile(initCgroup)
+	hostInit := isHostInit(initName, string(cgroup))
+
+	reachable := isTraversable(initRoot)
+	res.SetHeaders([]string{"path", "reachable", "readable"})
+	res.AddContent([]interface{}{initRoot, reachable, ""})
+	res.AddComment(fmt.Sprintf("PID 1 is %q and lives in another mount namespace.", initName))
+
+	if !hostInit {
+		// with shareProcessNamespace, PID 1 is the pause container of the pod
+		res.AddComment("PID 1 is not the host init but the process of another container, like the pause container of a pod sharing its process namespace, /proc/1/root is the root of a foreign container.")
+		if reachable {
+			res.RaiseSeverity(bucket.SeverityMedium)
+			res.AddComment("The root of the foreign container is reachable via /proc/1/root.")
+		}
+		return *res, nil
+	}
+
+	if !reachable {
+		res.AddComment("The host root can't be traversed via /proc/1/root, the container might lack CAP_SYS_PTRACE or be confined by an LSM.")
+		return *res, nil
+	}
+
+	res.RaiseSeverity(bucket.SeverityCritical)
+	res.AddComment("The host filesystem is reachable via /proc/1/root.")
+
+	var readables []string
+	for _, secret := range hostSecrets {
+		path := filepath.Join(initRoot, secret)
+		_, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		readable := isReadable(path)
+		if readable {
+			readables = append(readables, secret)
+		}
+		res.AddContent([]interface{}{path, true, readable})
+	}
+	if len(readables) > 0 {
+		res.AddComment(fmt.Sprintf("Host secrets are readable, contents were not printed: %v.", readables))
+	}
+
+	return *res, nil
+}
+
+// isHostInit checks that PID 1 is the init of the node, with its executable
+// name and the content of its cgroup file, empty if it can't be read.
+func isHostInit(name string, cgroup string) bool {
+	found := false
+	for _, init := range pidnamespace.HostInits {
+		if name == init {
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+	for _, c := range containerCgroups {
+		if strings.Contains(cgroup, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTraversable only lists the first entry of the directory.
+func isTraversable(path string) bool {
+	dir, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer dir.Close()
+	_, err = dir.Readdirnames(1)
+	return err == nil
+}
+
+// isReadable reads a single byte of the file and discards it.
+func isReadable(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	_, err = file.Read(make([]byte, 1))
+	return err == nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewHostRootBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewHostRootBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
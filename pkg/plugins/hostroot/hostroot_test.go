@@ -0,0 +1,25 @@
+package hostroot
+
+import "testing"
+
+func TestIsHostInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		process  string
+		cgroup   string
+		hostInit bool
+	}{
+		{"systemd cgroup v2", "systemd", "0::/init.scope\n", true},
+		{"init without cgroup", "init", "", true},
+		{"pause container", "pause", "0::/kubepods.slice/kubepods-besteffort.slice/cri-containerd-1a2b.scope\n", false},
+		{"init in a container", "init", "12:memory:/docker/1a2b\n", false},
+		{"tini", "tini", "0::/\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isHostInit(tt.process, tt.cgroup); got != tt.hostInit {
+				t.Errorf("isHostInit(%q, %q) = %v, want %v", tt.process, tt.cgroup, got, tt.hostInit)
+			}
+		})
+	}
+}
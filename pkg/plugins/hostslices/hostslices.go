@@ -0,0 +1,121 @@
+package hostslices
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/mount"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "hostslices"
+	bucketDescription = "HostSlices checks if the host systemd slices or cgroupfs hierarchies are visible and writable from the container."
+)
+
+var bucketAliases = []string{"hostslice", "slices"}
+
+// cgroupfsNames are the top level cgroups created by the cgroupfs driver,
+// the systemd driver uses .slice and .scope units instead.
+var cgroupfsNames = []string{"kubepods", "docker", "kubelet", "system"}
+
+type Bucket struct{}
+
+type slice struct {
+	hierarchy string
+	name      string
+	writable  bool
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	mnts, err := mount.Mounts()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	var slices []slice
+	hierarchies := 0
+	for _, mnt := range mnts {
+		if mnt.Filesystem != "cgroup" && mnt.Filesystem != "cgroup2" {
+			continue
+		}
+		hierarchies++
+		entries, err := os.ReadDir(mnt.Path)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && isHostCgroup(entry.Name()) {
+				path := filepath.Join(mnt.Path, entry.Name())
+				slices = append(slices, slice{
+					hierarchy: mnt.Path,
+					name:      entry.Name(),
+					// access does not modify anything and fails on read-only mounts
+					writable: unix.Access(path, unix.W_OK) == nil,
+				})
+			}
+		}
+	}
+
+	if hierarchies == 0 {
+		res.AddComment("No cgroup filesystem is mounted in the container.")
+		return *res, nil
+	}
+
+	res.SetHeaders([]string{"hierarchy", "slice", "writable"})
+	writable := 0
+	for _, s := range slices {
+		res.AddContent([]interface{}{s.hierarchy, s.name, s.writable})
+		if s.writable {
+			writable++
+		}
+	}
+
+	switch {
+	case writable > 0:
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("%d host slices are writable, processes could be moved or limits changed outside of the container.", writable))
+	case len(slices) > 0:
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("%d host slices are visible, the cgroup namespace isolation seems insufficient.", len(slices)))
+	default:
+		res.AddComment("No host slice is visible, the container only sees its own cgroups.")
+	}
+
+	return *res, nil
+}
+
+func isHostCgroup(name string) bool {
+	if strings.HasSuffix(name, ".slice") || strings.HasSuffix(name, ".scope") {
+		return true
+	}
+	for _, n := range cgroupfsNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewHostSlicesBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewHostSlicesBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,119 @@
+package imageuser
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	bucketName        = "imageuser"
+	bucketDescription = "ImageUser determines if the effective user comes from the image USER or from the runAsUser field of the pod securityContext."
+
+	sourceContainer = "runAsUser (container)"
+	sourcePod       = "runAsUser (pod)"
+	sourceImage     = "image"
+	sourceUnknown   = "unknown"
+)
+
+var bucketAliases = []string{"imageusers", "dockeruser"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	uid := os.Geteuid()
+
+	source := sourceUnknown
+	client, err := n.config.OptionalClient()
+	if err != nil {
+		res.AddComment(fmt.Sprintf("No client could be loaded, the user source can't be determined from the pod securityContext: %s", err))
+	} else {
+		pod, err := automaticontext.CurrentPod(ctx, client, n.config.Namespace)
+		if err != nil {
+			res.AddComment(fmt.Sprintf("Failed to retrieve the pod spec, the user source can't be determined: %s", err))
+		} else {
+			source = userSource(pod, uid)
+			if source == sourceUnknown {
+				res.AddComment("The container could not be identified among the containers of the pod.")
+			}
+		}
+	}
+
+	root := uid == 0
+	res.SetHeaders([]string{"userSource", "effectiveUID", "root"})
+	res.AddContent([]interface{}{source, uid, root})
+
+	switch {
+	case source == sourceImage && root:
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment("The image defaults to root and the pod does not override the user.")
+	case source == sourceImage:
+		res.AddComment("The image specifies a non-root USER.")
+	case source != sourceUnknown && !root:
+		res.AddComment("The non-root user is enforced by the pod, the image itself might still default to root.")
+	case root:
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("The container is running as root.")
+	}
+
+	return *res, nil
+}
+
+// userSource finds where the user of the current container comes from. The
+// current container is the only one of the pod or the only one whose
+// resolved runAsUser is compatible with the effective UID.
+func userSource(pod *v1.Pod, uid int) string {
+	var candidates []string
+	for _, c := range pod.Spec.Containers {
+		s, runAsUser := containerUser(pod, c)
+		if len(pod.Spec.Containers) == 1 {
+			return s
+		}
+		if runAsUser == nil || *runAsUser == int64(uid) {
+			candidates = append(candidates, s)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return sourceUnknown
+}
+
+// containerUser returns the source of the user of the container, the
+// container securityContext overrides the pod one.
+func containerUser(pod *v1.Pod, c v1.Container) (string, *int64) {
+	if c.SecurityContext != nil && c.SecurityContext.RunAsUser != nil {
+		return sourceContainer, c.SecurityContext.RunAsUser
+	}
+	if pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.RunAsUser != nil {
+		return sourcePod, pod.Spec.SecurityContext.RunAsUser
+	}
+	return sourceImage, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewImageUserBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewImageUserBucket(config bucket.Config) (*Bucket, error) {
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -0,0 +1,87 @@
+package impersonate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/authorization"
+	v1 "k8s.io/api/authorization/v1"
+)
+
+const (
+	bucketName        = "impersonate"
+	bucketDescription = "Impersonate checks if the token can impersonate users, groups or service accounts."
+)
+
+var bucketAliases = []string{"impersonation", "imp"}
+
+// users and groups are cluster scoped while service accounts impersonation
+// can be granted per namespace
+// https://kubernetes.io/docs/reference/access-authn-authz/authentication/#user-impersonation
+var resources = []struct {
+	resource   string
+	namespaced bool
+}{
+	{"users", false},
+	{"groups", false},
+	{"serviceaccounts", true},
+}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+	res.AddComment(fmt.Sprintf("Checking impersonation permissions in the %q namespace.", n.config.Namespace))
+
+	res.SetHeaders([]string{"resource", "allowed", "reason"})
+	var allowedResources []string
+	for _, r := range resources {
+		attributes := v1.ResourceAttributes{
+			Verb:     "impersonate",
+			Resource: r.resource,
+		}
+		if r.namespaced {
+			attributes.Namespace = n.config.Namespace
+		}
+		allowed, reason, err := authorization.CanI(ctx, n.config.Client, attributes)
+		if err != nil {
+			return bucket.Results{}, err
+		}
+		if allowed {
+			allowedResources = append(allowedResources, r.resource)
+		}
+		res.AddContent([]interface{}{r.resource, allowed, reason})
+	}
+
+	if len(allowedResources) > 0 {
+		res.RaiseSeverity(bucket.SeverityCritical)
+		res.AddComment(fmt.Sprintf("The token can impersonate %v, it effectively holds the permissions of the impersonated identities, for example with kubectl --as.", allowedResources))
+	}
+
+	return *res, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewImpersonateBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewImpersonateBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
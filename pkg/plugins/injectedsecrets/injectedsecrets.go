@@ -0,0 +1,172 @@
+package injectedsecrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "injectedsecrets"
+	bucketDescription = "InjectedSecrets looks for secrets rendered on disk by injectors like Vault Agent or consul-template."
+
+	// only the beginning of the files is inspected
+	sniffSize = 4096
+)
+
+var bucketAliases = []string{"vault", "renderedsecrets"}
+
+// secretDirs are the default output directories of the secrets injectors and
+// the common mount points for secrets.
+var secretDirs = []string{
+	"/vault/secrets",
+	"/run/secrets",
+	"/etc/secrets",
+	"/etc/consul-template",
+	"/secrets",
+}
+
+// excludedDirs are covered by other buckets.
+var excludedDirs = []string{
+	"/run/secrets/kubernetes.io",
+	"/run/secrets/eks.amazonaws.com",
+	"/run/secrets/pods.eks.amazonaws.com",
+	"/run/secrets/azure",
+}
+
+var secretMarkers = [][]byte{
+	[]byte("private key-----"),
+	[]byte("password"),
+	[]byte("passwd"),
+	[]byte("secret"),
+	[]byte("token"),
+	[]byte("api_key"),
+	[]byte("apikey"),
+	[]byte("access_key"),
+	[]byte("credentials"),
+}
+
+type Bucket struct{}
+
+type secretFile struct {
+	path            string
+	looksLikeSecret bool
+	size            int64
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	var files []secretFile
+	for _, dir := range secretDirs {
+		// walk errors are ignored, most of the directories do not exist
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if isExcluded(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// Kubernetes volumes use hidden timestamped directories and
+			// symlinks to update their content atomically
+			if strings.Contains(path, "/..") {
+				return nil
+			}
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+			files = append(files, secretFile{path, looksLikeSecret(path), info.Size()})
+			return nil
+		})
+	}
+
+	if len(files) == 0 {
+		res.AddComment("No rendered secret file was found.")
+		return *res, nil
+	}
+
+	res.SetHeaders([]string{"path", "looksLikeSecret", "size"})
+	secrets := 0
+	for _, f := range files {
+		res.AddContent([]interface{}{f.path, f.looksLikeSecret, f.size})
+		if f.looksLikeSecret {
+			secrets++
+		}
+	}
+
+	res.AddComment("Contents are not printed, files were inspected for common secret markers.")
+	if secrets > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("%d files look like decrypted secrets readable from the container.", secrets))
+	}
+
+	return *res, nil
+}
+
+func isExcluded(path string) bool {
+	for _, dir := range excludedDirs {
+		if path == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeSecret checks the name and the beginning of the file for common
+// secret markers, it never returns the content.
+func looksLikeSecret(path string) bool {
+	if containsMarker([]byte(filepath.Base(path))) {
+		return true
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	buf := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false
+	}
+	return containsMarker(buf[:n])
+}
+
+func containsMarker(b []byte) bool {
+	lower := bytes.ToLower(b)
+	for _, marker := range secretMarkers {
+		if bytes.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewInjectedSecretsBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewInjectedSecretsBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,125 @@
+package internalui
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/egress"
+)
+
+const (
+	bucketName        = "internalui"
+	bucketDescription = "InternalUI resolves and probes well-known management UIs and services of the cluster, like the dashboard or Grafana."
+)
+
+var bucketAliases = []string{"internaluis", "uis", "dashboard"}
+
+type target struct {
+	name    string
+	address string
+}
+
+// defaultTargets use the conventional service names and namespaces of the
+// official manifests and Helm charts, the "svc" suffix is completed by the
+// search domains of the pod.
+var defaultTargets = []target{
+	{"kubernetes-dashboard", "kubernetes-dashboard.kubernetes-dashboard.svc:443"},
+	{"kubernetes-dashboard", "kubernetes-dashboard-kong-proxy.kubernetes-dashboard.svc:443"},
+	{"kube-state-metrics", "kube-state-metrics.kube-system.svc:8080"},
+	{"prometheus", "prometheus-server.monitoring.svc:80"},
+	{"prometheus", "prometheus-operated.monitoring.svc:9090"},
+	{"grafana", "grafana.monitoring.svc:80"},
+	{"argocd", "argocd-server.argocd.svc:443"},
+}
+
+type Bucket struct {
+	targets []target
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	resolved := make([]bool, len(n.targets))
+	var addresses []string
+	var indexes []int
+	for i, t := range n.targets {
+		host, _, err := net.SplitHostPort(t.address)
+		if err != nil {
+			return bucket.Results{}, err
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, egress.DefaultTimeout)
+		_, err = net.DefaultResolver.LookupHost(lookupCtx, host)
+		cancel()
+		if err == nil {
+			resolved[i] = true
+			addresses = append(addresses, t.address)
+			indexes = append(indexes, i)
+		}
+	}
+
+	// only probe the resolved services, the others are most likely not
+	// installed in the cluster
+	reachable := make([]bool, len(n.targets))
+	for j, probe := range egress.TCPAll(ctx, addresses, egress.DefaultTimeout) {
+		reachable[indexes[j]] = probe.Reachable
+	}
+
+	res.SetHeaders([]string{"service", "address", "resolved", "reachable"})
+	var reachableNames []string
+	for i, t := range n.targets {
+		res.AddContent([]interface{}{t.name, t.address, resolved[i], reachable[i]})
+		if reachable[i] {
+			reachableNames = append(reachableNames, t.name)
+		}
+	}
+
+	if len(reachableNames) > 0 {
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("Management UIs are reachable from the pod, the network might not be segmented: %v.", reachableNames))
+	}
+
+	return *res, nil
+}
+
+// parseTargets parses targets in the "name=host:port" format.
+func parseTargets(entries []string) ([]target, error) {
+	var targets []target
+	for _, entry := range entries {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid internal UI %q, expected the name=host:port format", entry)
+		}
+		if _, _, err := net.SplitHostPort(kv[1]); err != nil {
+			return nil, fmt.Errorf("invalid internal UI %q: %w", entry, err)
+		}
+		targets = append(targets, target{name: kv[0], address: kv[1]})
+	}
+	return targets, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewInternalUIBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: false,
+	})
+}
+
+func NewInternalUIBucket(config bucket.Config) (*Bucket, error) {
+	if len(config.InternalUIs) == 0 {
+		return &Bucket{targets: defaultTargets}, nil
+	}
+	targets, err := parseTargets(config.InternalUIs)
+	if err != nil {
+		return nil, err
+	}
+	return &Bucket{targets: targets}, nil
+}
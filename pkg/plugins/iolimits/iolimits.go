@@ -0,0 +1,241 @@
+package iolimits
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/cgroups"
+)
+
+const (
+	bucketName        = "iolimits"
+	bucketDescription = "IOLimits reports the block IO bandwidth and IOPS limits of the container cgroup per device."
+
+	unlimited = "max"
+)
+
+var bucketAliases = []string{"iolimit", "io", "blkio"}
+
+type Bucket struct{}
+
+type deviceIO struct {
+	readBPS      string
+	writeBPS     string
+	readIOPS     string
+	writeIOPS    string
+	readBytes    string
+	writtenBytes string
+}
+
+func newDeviceIO() *deviceIO {
+	return &deviceIO{
+		readBPS:   unlimited,
+		writeBPS:  unlimited,
+		readIOPS:  unlimited,
+		writeIOPS: unlimited,
+	}
+}
+
+func (d deviceIO) isLimited() bool {
+	return d.readBPS != unlimited || d.writeBPS != unlimited || d.readIOPS != unlimited || d.writeIOPS != unlimited
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	var devices map[string]*deviceIO
+	var err error
+	if cgroups.IsV2() {
+		res.AddComment("Limits were read from the io controller of cgroups v2.")
+		devices, err = readV2()
+	} else {
+		res.AddComment("Limits were read from the blkio controller of cgroups v1.")
+		devices, err = readV1()
+	}
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	// sort the devices for the output to be stable
+	ids := make([]string, 0, len(devices))
+	for id := range devices {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	res.SetHeaders([]string{"device", "readBPS", "writeBPS", "readIOPS", "writeIOPS", "readBytes", "writtenBytes"})
+	limited := false
+	for _, id := range ids {
+		d := devices[id]
+		limited = limited || d.isLimited()
+		res.AddContent([]interface{}{deviceName(id), d.readBPS, d.writeBPS, d.readIOPS, d.writeIOPS, d.readBytes, d.writtenBytes})
+	}
+
+	if !limited {
+		res.AddComment("No IO limit is set, the container can saturate the node disks and disturb its neighbors.")
+	}
+
+	return *res, nil
+}
+
+// readV2 reads io.max and io.stat, the io controller must be enabled in the
+// cgroup for these files to exist.
+// https://docs.kernel.org/admin-guide/cgroup-v2.html#io-interface-files
+func readV2() (map[string]*deviceIO, error) {
+	devices := map[string]*deviceIO{}
+
+	maxLines, err := readDeviceLines(filepath.Join(cgroups.Root, "io.max"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read io limits, the io controller might not be enabled: %w", err)
+	}
+	for _, fields := range maxLines {
+		d := getDevice(devices, fields[0])
+		for key, value := range parseNestedKeys(fields[1:]) {
+			switch key {
+			case "rbps":
+				d.readBPS = value
+			case "wbps":
+				d.writeBPS = value
+			case "riops":
+				d.readIOPS = value
+			case "wiops":
+				d.writeIOPS = value
+			}
+		}
+	}
+
+	statLines, err := readDeviceLines(filepath.Join(cgroups.Root, "io.stat"))
+	if err != nil {
+		return nil, err
+	}
+	for _, fields := range statLines {
+		d := getDevice(devices, fields[0])
+		keys := parseNestedKeys(fields[1:])
+		d.readBytes = keys["rbytes"]
+		d.writtenBytes = keys["wbytes"]
+	}
+
+	return devices, nil
+}
+
+// readV1 reads the blkio throttling files.
+// https://docs.kernel.org/admin-guide/cgroup-v1/blkio-controller.html
+func readV1() (map[string]*deviceIO, error) {
+	devices := map[string]*deviceIO{}
+	blkio := filepath.Join(cgroups.Root, "blkio")
+
+	limits := map[string]func(d *deviceIO, value string){
+		"blkio.throttle.read_bps_device":   func(d *deviceIO, v string) { d.readBPS = v },
+		"blkio.throttle.write_bps_device":  func(d *deviceIO, v string) { d.writeBPS = v },
+		"blkio.throttle.read_iops_device":  func(d *deviceIO, v string) { d.readIOPS = v },
+		"blkio.throttle.write_iops_device": func(d *deviceIO, v string) { d.writeIOPS = v },
+	}
+	for file, set := range limits {
+		lines, err := readDeviceLines(filepath.Join(blkio, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blkio limits, the blkio controller might not be mounted: %w", err)
+		}
+		for _, fields := range lines {
+			set(getDevice(devices, fields[0]), fields[1])
+		}
+	}
+
+	lines, err := readDeviceLines(filepath.Join(blkio, "blkio.throttle.io_service_bytes"))
+	if err != nil {
+		return nil, err
+	}
+	for _, fields := range lines {
+		// lines are in the form "8:0 Read 1024"
+		if len(fields) != 3 {
+			continue
+		}
+		switch fields[1] {
+		case "Read":
+			getDevice(devices, fields[0]).readBytes = fields[2]
+		case "Write":
+			getDevice(devices, fields[0]).writtenBytes = fields[2]
+		}
+	}
+
+	return devices, nil
+}
+
+func getDevice(devices map[string]*deviceIO, id string) *deviceIO {
+	d, found := devices[id]
+	if !found {
+		d = newDeviceIO()
+		devices[id] = d
+	}
+	return d
+}
+
+// readDeviceLines returns the fields of the lines starting with a device
+// "major:minor" identifier, it skips the others like the "Total" line.
+func readDeviceLines(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines [][]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.Contains(fields[0], ":") {
+			continue
+		}
+		lines = append(lines, fields)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// parseNestedKeys parses fields in the "key=value" format.
+func parseNestedKeys(fields []string) map[string]string {
+	keys := map[string]string{}
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) == 2 {
+			keys[kv[0]] = kv[1]
+		}
+	}
+	return keys
+}
+
+// deviceName tries to resolve the "major:minor" identifier to the block
+// device name via sysfs.
+func deviceName(id string) string {
+	link, err := os.Readlink(filepath.Join("/sys/dev/block", id))
+	if err != nil {
+		return id
+	}
+	return fmt.Sprintf("%s (%s)", filepath.Base(link), id)
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewIOLimitsBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewIOLimitsBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,112 @@
+package kernelcmdline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "kernelcmdline"
+	bucketDescription = "KernelCmdline reads the host kernel boot parameters and flags the ones disabling security mitigations."
+
+	cmdlinePath = "/proc/cmdline"
+)
+
+var bucketAliases = []string{"bootparams", "kcmdline"}
+
+// weakeningParams disable security features or mitigations, they must match
+// the whole parameter, value included.
+var weakeningParams = []string{
+	"mitigations=off",
+	"nopti",
+	"pti=off",
+	"nospectre_v1",
+	"nospectre_v2",
+	"spectre_v2=off",
+	"spec_store_bypass_disable=off",
+	"nosmep",
+	"nosmap",
+	"nokaslr",
+	"selinux=0",
+	"apparmor=0",
+	"security=none",
+	"enforcing=0",
+	"audit=0",
+	"lockdown=none",
+	"module.sig_enforce=0",
+}
+
+// notablePrefixes reveal the host configuration without weakening it.
+var notablePrefixes = []string{"root=", "init=", "rdinit=", "console=", "systemd.unified_cgroup_hierarchy=", "cgroup_no_v1=", "lsm=", "security=", "BOOT_IMAGE="}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	content, err := os.ReadFile(cmdlinePath)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	cmdline := strings.TrimSpace(string(content))
+
+	var weakening, notable []string
+	for _, param := range strings.Fields(cmdline) {
+		switch {
+		case contains(weakeningParams, param):
+			weakening = append(weakening, param)
+		case hasPrefix(param, notablePrefixes):
+			notable = append(notable, param)
+		}
+	}
+
+	res.SetHeaders([]string{"cmdline", "weakening", "notable"})
+	res.AddContent([]interface{}{cmdline, weakening, notable})
+	res.AddComment("/proc/cmdline is not namespaced, these are the boot parameters of the host kernel.")
+
+	if len(weakening) > 0 {
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("Security features or mitigations are disabled on the host kernel: %v.", weakening))
+	}
+
+	return *res, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewKernelCmdlineBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewKernelCmdlineBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
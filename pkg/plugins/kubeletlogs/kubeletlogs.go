@@ -0,0 +1,178 @@
+package kubeletlogs
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/token"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	bucketName        = "kubeletlogs"
+	bucketDescription = "KubeletLogs checks if the service account token can retrieve the logs of other pods of the node through the kubelet API."
+
+	kubeletPort = "10250"
+
+	requestTimeout = 2 * time.Second
+	// the pods list can be large on busy nodes
+	maxPodsListSize = 10 << 20
+)
+
+var bucketAliases = []string{"containerlogs", "kubeletlog"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	pod, err := automaticontext.CurrentPod(ctx, n.config.Client, n.config.Namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	if pod.Status.HostIP == "" {
+		return bucket.Results{}, fmt.Errorf("host IP of pod %q is unknown", pod.Name)
+	}
+	saToken, err := os.ReadFile(token.ServiceAccountToken)
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to read the service account token: %w", err)
+	}
+
+	k := kubelet{
+		endpoint: "https://" + net.JoinHostPort(pod.Status.HostIP, kubeletPort),
+		token:    strings.TrimSpace(string(saToken)),
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				// kubelets usually serve a self signed certificate
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+
+	res.SetHeaders([]string{"endpoint", "accessible", "status", "samplePod"})
+
+	pods, status, err := k.pods(ctx)
+	if err != nil {
+		res.AddContent([]interface{}{k.endpoint + "/pods", false, status, ""})
+		res.AddComment("The pods of the node can't be listed through the kubelet, logs can't be retrieved without knowing the pods.")
+		return *res, nil
+	}
+	res.AddContent([]interface{}{k.endpoint + "/pods", true, status, ""})
+
+	// prefer a pod from another namespace to demonstrate the exposure
+	sample := samplePod(pods, pod)
+	if sample == nil {
+		res.AddComment("No other pod with containers was found on the node.")
+		return *res, nil
+	}
+	path := fmt.Sprintf("/containerLogs/%s/%s/%s", sample.Namespace, sample.Name, sample.Spec.Containers[0].Name)
+	samplePod := sample.Namespace + "/" + sample.Name
+	status, err = k.logs(ctx, path)
+	res.AddContent([]interface{}{k.endpoint + path, err == nil, status, samplePod})
+
+	if err == nil {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("Logs of the %d pods of the node can be retrieved through the kubelet, contents were not printed.", len(pods)))
+	} else {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("The pods of the node can be listed through the kubelet but logs can't be retrieved.")
+	}
+
+	return *res, nil
+}
+
+type kubelet struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func (k kubelet) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.endpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.token)
+	return k.client.Do(req)
+}
+
+func (k kubelet) pods(ctx context.Context) ([]v1.Pod, string, error) {
+	resp, err := k.get(ctx, "/pods")
+	if err != nil {
+		return nil, err.Error(), err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.Status, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	var list v1.PodList
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPodsListSize)).Decode(&list); err != nil {
+		return nil, err.Error(), err
+	}
+	return list.Items, resp.Status, nil
+}
+
+// logs only requests the last byte of logs to confirm the access.
+func (k kubelet) logs(ctx context.Context, path string) (string, error) {
+	resp, err := k.get(ctx, path+"?tailLines=1&limitBytes=1")
+	if err != nil {
+		return err.Error(), err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.Status, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Status, nil
+}
+
+func samplePod(pods []v1.Pod, self *v1.Pod) *v1.Pod {
+	var sameNamespace *v1.Pod
+	for i := range pods {
+		p := &pods[i]
+		if p.UID == self.UID || len(p.Spec.Containers) == 0 {
+			continue
+		}
+		if p.Namespace != self.Namespace {
+			return p
+		}
+		if sameNamespace == nil {
+			sameNamespace = p
+		}
+	}
+	return sameNamespace
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewKubeletLogsBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: true,
+	})
+}
+
+func NewKubeletLogsBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package localcomponents
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/egress"
+)
+
+const (
+	bucketName        = "localcomponents"
+	bucketDescription = "LocalComponents probes the loopback ports and abstract sockets of node components, reachable only when sharing the host network namespace."
+
+	unixSocketsPath = "/proc/net/unix"
+)
+
+var bucketAliases = []string{"loopback", "localsockets"}
+
+// components listen on the node loopback by default.
+var components = []struct {
+	name    string
+	address string
+}{
+	{"kubelet healthz", "127.0.0.1:10248"},
+	{"kube-proxy metrics", "127.0.0.1:10249"},
+	{"kube-proxy healthz", "127.0.0.1:10256"},
+	{"kube-controller-manager", "127.0.0.1:10257"},
+	{"kube-scheduler", "127.0.0.1:10259"},
+	{"etcd", "127.0.0.1:2379"},
+	{"etcd metrics", "127.0.0.1:2381"},
+	{"containerd metrics", "127.0.0.1:1338"},
+}
+
+// abstractSockets are prefixes of abstract unix sockets of container
+// runtimes, they are scoped to the network namespace.
+var abstractSockets = []struct {
+	name   string
+	prefix string
+}{
+	{"containerd-shim", "@/containerd-shim/"},
+	{"containerd", "@containerd"},
+	{"dockerd", "@docker"},
+}
+
+type Bucket struct{}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	addresses := make([]string, len(components))
+	for i, c := range components {
+		addresses[i] = c.address
+	}
+	probes := egress.TCPAll(ctx, addresses, egress.DefaultTimeout)
+
+	res.SetHeaders([]string{"component", "endpoint", "reachable"})
+	var reachables []string
+	for i, p := range probes {
+		res.AddContent([]interface{}{components[i].name, p.Address, p.Reachable})
+		if p.Reachable {
+			reachables = append(reachables, components[i].name)
+		}
+	}
+
+	sockets, err := readAbstractSockets()
+	if err != nil {
+		res.AddComment(fmt.Sprintf("Failed to read %s: %s", unixSocketsPath, err))
+	}
+	for _, s := range abstractSockets {
+		for _, path := range sockets {
+			if strings.HasPrefix(path, s.prefix) {
+				res.AddContent([]interface{}{s.name, path, true})
+				reachables = append(reachables, s.name)
+			}
+		}
+	}
+
+	if len(reachables) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("Node components are reachable on the loopback, the container most likely shares the host network namespace, see the hostnetwork bucket: %v.", reachables))
+	}
+
+	return *res, nil
+}
+
+// readAbstractSockets returns the paths of the abstract unix sockets, the
+// ones starting with "@", they are listed even if not connected.
+func readAbstractSockets() ([]string, error) {
+	file, err := os.Open(unixSocketsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sockets []string
+	scanner := bufio.NewScanner(file)
+	// skip the header line
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// the path is the optional eighth field
+		if len(fields) < 8 || !strings.HasPrefix(fields[7], "@") {
+			continue
+		}
+		sockets = append(sockets, fields[7])
+	}
+
+	return sockets, scanner.Err()
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewLocalComponentsBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: false,
+	})
+}
+
+func NewLocalComponentsBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
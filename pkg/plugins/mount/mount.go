@@ -2,8 +2,10 @@ package mount
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -12,29 +14,88 @@ import (
 
 const (
 	bucketName        = "mount"
-	bucketDescription = "Mount shows all mounted devices in the container."
+	bucketDescription = "Mount shows all mounted devices in the container and flags the ones exposing the node."
 
-	mountPath = "/proc/mounts"
+	mountPath     = "/proc/mounts"
+	mountInfoPath = "/proc/self/mountinfo"
 )
 
 var bucketAliases = []string{"mounts", "mn"}
 
+var runtimeSockets = []string{"docker.sock", "containerd.sock", "crio.sock", "cri-dockerd.sock"}
+
+// managedBindPrefixes are the host directories of the files bound in the
+// containers by the kubelet and the runtimes, like volumes or /etc/hosts.
+var managedBindPrefixes = []string{
+	"/var/lib/kubelet/",
+	"/var/lib/docker/",
+	"/var/lib/containerd/",
+	"/var/lib/containers/",
+	"/run/containerd/",
+	"/var/run/containerd/",
+	"/var/lib/k0s/kubelet/",
+	"/var/lib/rancher/",
+}
+
 type Bucket struct{}
 
-func (m Bucket) Run() (bucket.Results, error) {
-	values, err := Mounts()
+func (m Bucket) Run(_ context.Context) (bucket.Results, error) {
+	values, err := MountInfos()
 	if err != nil {
 		return bucket.Results{}, err
 	}
 	res := bucket.NewResults(bucketName)
-	res.SetHeaders([]string{"device", "path", "filesystem", "flags"})
+	res.SetHeaders([]string{"mountpoint", "source", "type", "options", "flag"})
+	flagged := 0
 	for _, m := range values {
-		res.AddContent([]interface{}{m.Device, m.Path, m.Filesystem, m.Flags})
+		flag, severity := suspicious(m)
+		if flag != "" {
+			flagged++
+			res.RaiseSeverity(severity)
+		}
+		res.AddContent([]interface{}{m.MountPoint, m.Source, m.FSType, m.Options, flag})
 	}
 	res.AddComment(fmt.Sprintf("%d devices are mounted.", len(values)))
+	if flagged > 0 {
+		res.AddComment(fmt.Sprintf("%d mounts are suspicious, they might expose the node to the container.", flagged))
+	}
 	return *res, nil
 }
 
+// suspicious returns a description of the mount if it might expose the node,
+// with the associated severity.
+func suspicious(m MountInfo) (string, bucket.Severity) {
+	for _, socket := range runtimeSockets {
+		if strings.HasSuffix(m.MountPoint, socket) || strings.HasSuffix(m.Root, socket) {
+			return "container runtime socket", bucket.SeverityHigh
+		}
+	}
+	if m.FSType == "proc" && m.MountPoint != "/proc" && !strings.HasPrefix(m.MountPoint, "/proc/") {
+		return "host procfs", bucket.SeverityHigh
+	}
+	if !m.ReadWrite() {
+		return "", bucket.SeverityNone
+	}
+	// a bind mount of a subdirectory has its path in the source filesystem
+	// as root, the kubelet and the runtimes bind their own files
+	if m.Root != "/" && isBlockDevice(m.Source) {
+		for _, prefix := range managedBindPrefixes {
+			if strings.HasPrefix(m.Root, prefix) {
+				return "", bucket.SeverityNone
+			}
+		}
+		return "rw bind mount of a host path", bucket.SeverityMedium
+	}
+	if m.Root == "/" && isBlockDevice(m.Source) && m.MountPoint != "/" {
+		return "rw host filesystem", bucket.SeverityHigh
+	}
+	return "", bucket.SeverityNone
+}
+
+func isBlockDevice(source string) bool {
+	return strings.HasPrefix(source, "/dev/")
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
@@ -59,6 +120,16 @@ type Mount struct {
 	Flags      string
 }
 
+// ReadWrite returns true if the mount has the rw flag.
+func (m Mount) ReadWrite() bool {
+	for _, flag := range strings.Split(m.Flags, ",") {
+		if flag == "rw" {
+			return true
+		}
+	}
+	return false
+}
+
 func Mounts() ([]Mount, error) {
 	file, err := os.Open(mountPath)
 	if err != nil {
@@ -78,3 +149,87 @@ func Mounts() ([]Mount, error) {
 	}
 	return mounts, nil
 }
+
+// MountInfo is an entry of /proc/self/mountinfo, Root is the path of the
+// mount in its source filesystem, different from / for bind mounts.
+type MountInfo struct {
+	Root       string
+	MountPoint string
+	FSType     string
+	Source     string
+	Options    string
+	// SuperOptions are the options of the filesystem, like the layers of an
+	// overlay
+	SuperOptions string
+}
+
+// ReadWrite returns true if the mount has the rw flag.
+func (m MountInfo) ReadWrite() bool {
+	for _, flag := range strings.Split(m.Options, ",") {
+		if flag == "rw" {
+			return true
+		}
+	}
+	return false
+}
+
+// MountInfos parses /proc/self/mountinfo, the format is described in
+// proc(5), optional fields are terminated by a single hyphen.
+func MountInfos() ([]MountInfo, error) {
+	file, err := os.Open(mountInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []MountInfo
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		separator := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				separator = i
+				break
+			}
+		}
+		if separator == -1 || len(fields) < separator+3 {
+			return nil, fmt.Errorf("format of %s file is incorrect, missing fields", mountInfoPath)
+		}
+		info := MountInfo{
+			Root:       unescape(fields[3]),
+			MountPoint: unescape(fields[4]),
+			FSType:     fields[separator+1],
+			Source:     unescape(fields[separator+2]),
+			Options:    fields[5],
+		}
+		if len(fields) > separator+3 {
+			info.SuperOptions = fields[separator+3]
+		}
+		mounts = append(mounts, info)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+// unescape decodes the octal escapes of spaces, tabs, newlines and
+// backslashes in the paths of mountinfo.
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
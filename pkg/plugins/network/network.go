@@ -0,0 +1,126 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/hostnetwork"
+)
+
+const (
+	bucketName        = "network"
+	bucketDescription = "Network lists the network interfaces of the container with their addresses and the default route, flagging the signs of the host network."
+
+	sysClassNet = "/sys/class/net"
+)
+
+var bucketAliases = []string{"net", "interfaces", "netns"}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	res.SetHeaders([]string{"interface", "addresses", "flags", "mtu", "physical"})
+	var physical, node, up []string
+	for _, i := range interfaces {
+		var addresses []string
+		addrs, err := i.Addrs()
+		if err != nil {
+			res.AddComment(fmt.Sprintf("Failed to list the addresses of %s: %s", i.Name, err))
+		}
+		for _, a := range addrs {
+			addresses = append(addresses, a.String())
+		}
+		isPhysical := isPhysicalInterface(i.Name)
+		res.AddContent([]interface{}{i.Name, addresses, i.Flags.String(), i.MTU, isPhysical})
+
+		if i.Flags&net.FlagLoopback != 0 || i.Flags&net.FlagUp == 0 {
+			continue
+		}
+		up = append(up, i.Name)
+		if isPhysical {
+			physical = append(physical, i.Name)
+		}
+		if hostnetwork.IsNodeInterface(i.Name) {
+			node = append(node, i.Name)
+		}
+	}
+
+	// the routing table is only available for IPv4, its absence should not
+	// hide the interfaces
+	routes, err := hostnetwork.Routes()
+	if err != nil {
+		res.AddComment(fmt.Sprintf("Failed to read the routing table: %s", err))
+	}
+	for _, r := range routes {
+		if r.IsDefault() {
+			res.AddComment(fmt.Sprintf("The default route goes through %s on %s.", r.Gateway, r.Interface))
+		}
+	}
+
+	// the interfaces of a pod are virtual, a veth peer or a CNI device, a
+	// physical network card is usually only visible from the host network
+	// namespace, but it can also be the one of a sandbox VM, like with Kata,
+	// or moved to the pod by a CNI plugin like SR-IOV, so it needs another
+	// piece of evidence
+	var evidence []string
+	if len(physical) > 0 {
+		evidence = append(evidence, fmt.Sprintf("physical interfaces %v", physical))
+	}
+	if len(node) > 0 {
+		evidence = append(evidence, fmt.Sprintf("node side interfaces %v", node))
+	}
+	if len(up) > hostnetwork.MaxPodInterfaces {
+		evidence = append(evidence, fmt.Sprintf("%d non-loopback interfaces up", len(up)))
+	}
+	switch {
+	case len(physical) > 0 && len(evidence) > 1:
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("The container is likely on the host network: %s.", strings.Join(evidence, ", ")))
+	case len(evidence) > 0:
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment(fmt.Sprintf("The container might be on the host network: %s.", strings.Join(evidence, ", ")))
+	default:
+		res.AddComment("The container is unlikely to be on the host network, its interfaces are virtual.")
+	}
+
+	return *res, nil
+}
+
+// isPhysicalInterface checks if the device of the interface is backed by a
+// bus, like PCI, virtual devices are grouped under /sys/devices/virtual.
+func isPhysicalInterface(name string) bool {
+	target, err := os.Readlink(filepath.Join(sysClassNet, name))
+	if err != nil {
+		return false
+	}
+	return !strings.Contains(target, "/devices/virtual/")
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewNetworkBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewNetworkBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -2,6 +2,7 @@ package node
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -20,7 +21,7 @@ var bucketAliases = []string{"nodes", "n"}
 
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	cpuinfo, err := readCPUInfo()
 	if err != nil {
 		return bucket.Results{}, err
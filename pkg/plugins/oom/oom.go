@@ -0,0 +1,88 @@
+package oom
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/cgroups"
+)
+
+const (
+	bucketName        = "oom"
+	bucketDescription = "OOM reports the out of memory events and configuration of the container memory cgroup."
+
+	notAvailable = "n/a"
+)
+
+var bucketAliases = []string{"ooms", "oomkill"}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+	res.SetHeaders([]string{"cgroupVersion", "oomKill", "oomEvents", "oomKillDisabled"})
+
+	if cgroups.IsV2() {
+		// https://docs.kernel.org/admin-guide/cgroup-v2.html#memory-interface-files
+		events, err := cgroups.ReadFlatKeyed(filepath.Join(cgroups.Root, "memory.events"))
+		if err != nil {
+			return bucket.Results{}, fmt.Errorf("failed to read memory events, the memory controller might not be enabled: %w", err)
+		}
+		res.AddContent([]interface{}{"v2", valueOrNA(events, "oom_kill"), valueOrNA(events, "oom"), notAvailable})
+		res.AddComment("OOM killer can't be disabled under cgroups v2.")
+		addOOMKillComment(res, events["oom_kill"])
+		return *res, nil
+	}
+
+	// https://docs.kernel.org/admin-guide/cgroup-v1/memory.html#oom-control
+	control, err := cgroups.ReadFlatKeyed(filepath.Join(cgroups.Root, "memory", "memory.oom_control"))
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to read memory oom_control, the memory controller might not be mounted: %w", err)
+	}
+	disabled := control["oom_kill_disable"] == "1"
+	res.AddContent([]interface{}{"v1", valueOrNA(control, "oom_kill"), notAvailable, disabled})
+	if disabled {
+		res.AddComment("OOM killer is disabled, processes will hang instead of being killed when reaching the memory limit.")
+	}
+	if control["under_oom"] == "1" {
+		res.AddComment("The cgroup is currently under OOM.")
+	}
+	addOOMKillComment(res, control["oom_kill"])
+
+	return *res, nil
+}
+
+func addOOMKillComment(res *bucket.Results, oomKill string) {
+	if oomKill != "" && oomKill != "0" {
+		res.AddComment(fmt.Sprintf("Processes of the container were OOM killed %s times.", oomKill))
+	}
+}
+
+// valueOrNA returns the value for the key, older kernels might not expose all
+// the keys.
+func valueOrNA(values map[string]string, key string) string {
+	v, found := values[key]
+	if !found {
+		return notAvailable
+	}
+	return v
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewOOMBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewOOMBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
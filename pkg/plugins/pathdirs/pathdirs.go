@@ -0,0 +1,152 @@
+package pathdirs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "pathdirs"
+	bucketDescription = "PathDirs checks the directories of the PATH for write access, allowing to plant binaries hijacking later commands."
+)
+
+var bucketAliases = []string{"path", "binaryplanting"}
+
+// systemDirs are where the commands are usually found, a writable directory
+// placed before them shadows all the system binaries.
+var systemDirs = []string{"/usr/bin", "/bin", "/usr/sbin", "/sbin"}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if len(dirs) == 0 {
+		res.AddComment("The PATH env var is empty.")
+		return *res, nil
+	}
+
+	// the position of the last system directory, writable directories before
+	// it shadow at least part of the system binaries
+	lastSystem := -1
+	for i, dir := range dirs {
+		if contains(systemDirs, filepath.Clean(dir)) {
+			lastSystem = i
+		}
+	}
+
+	// root can write everywhere, a directory is only a finding if it belongs
+	// to someone else than the system directory it shadows, if any
+	root := os.Geteuid() == 0
+
+	res.SetHeaders([]string{"position", "directory", "exists", "writable", "owner"})
+	var early, late, skipped []string
+	for i, dir := range dirs {
+		if dir == "" {
+			// an empty entry is interpreted as the current directory
+			dir = "."
+		}
+		info, err := os.Stat(dir)
+		exists := err == nil && info.IsDir()
+		// access checks the permissions without creating any file
+		writable := exists && unix.Access(dir, unix.W_OK) == nil
+		uid := -1
+		if exists {
+			uid = owner(info)
+		}
+		res.AddContent([]interface{}{i, dir, exists, writable, uid})
+		if !writable {
+			continue
+		}
+		if root {
+			if sys := shadowed(dirs, i, lastSystem); sys == nil || uid == owner(sys) {
+				skipped = append(skipped, dir)
+				continue
+			}
+		}
+		if i < lastSystem {
+			early = append(early, dir)
+		} else {
+			late = append(late, dir)
+		}
+	}
+
+	if len(early) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("Directories placed before the system ones are writable, a planted binary would hijack common commands: %v.", early))
+	}
+	if len(late) > 0 {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment(fmt.Sprintf("Directories are writable, a planted binary would be found for missing commands: %v.", late))
+	}
+	if len(skipped) > 0 {
+		res.AddComment(fmt.Sprintf("You are root, writable directories owned like the system ones they shadow were not reported: %v.", skipped))
+	}
+
+	return *res, nil
+}
+
+// shadowed returns the information of the system directory found after the
+// position i in the PATH, or of the last one if there is none after it.
+func shadowed(dirs []string, i, lastSystem int) os.FileInfo {
+	if lastSystem < 0 {
+		return nil
+	}
+	for _, dir := range dirs[i:] {
+		if contains(systemDirs, filepath.Clean(dir)) {
+			if info, err := os.Stat(dir); err == nil {
+				return info
+			}
+		}
+	}
+	info, err := os.Stat(dirs[lastSystem])
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// owner returns the uid owning the file, or -1 if it can not be retrieved.
+func owner(info os.FileInfo) int {
+	if info == nil {
+		return -1
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return -1
+	}
+	return int(stat.Uid)
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewPathDirsBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewPathDirsBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
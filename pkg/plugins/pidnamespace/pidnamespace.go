@@ -1,6 +1,9 @@
 package pidnamespace
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"syscall"
 
 	"github.com/mitchellh/go-ps"
@@ -14,28 +17,84 @@ const (
 
 var bucketAliases = []string{"pidnamespaces", "pidns"}
 
+// hostProcessesThreshold is the number of visible processes above which the
+// namespace is unlikely to be the one of a container, pods rarely run more
+// than a handful of processes while nodes easily run hundreds.
+const hostProcessesThreshold = 100
+
+// HostInits are the usual first process of a node, containers start their
+// entrypoint or a minimal init like tini or dumb-init.
+var HostInits = []string{"systemd", "init"}
+
+// hostDaemons only run on the node, outside of any container.
+var hostDaemons = []string{"kubelet", "containerd", "dockerd", "crio", "systemd-journal", "sshd"}
+
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
-	deviceNumber, kubeletFound, pauseFound, err := getPIDNamespaceInfo()
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	deviceNumber, err := getPIDNamespaceDevice()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	processes, err := ps.Processes()
 	if err != nil {
 		return bucket.Results{}, err
 	}
 
+	var kubeletFound, pauseFound bool
+	for i := range processes {
+		kubeletFound = kubeletFound || processes[i].Executable() == "kubelet"
+		pauseFound = pauseFound || processes[i].Executable() == "pause"
+	}
+	evidence := hostEvidence(processes)
+
 	res := bucket.NewResults(bucketName)
-	res.SetHeaders([]string{"deviceNumber", "pauseFound", "kubeletFound"})
+	res.SetHeaders([]string{"deviceNumber", "processes", "pauseFound", "kubeletFound", "likelyHost"})
+	res.AddContent([]interface{}{deviceNumber, len(processes), pauseFound, kubeletFound, len(evidence) > 0})
 
 	if pauseFound {
 		res.AddComment("The pause process was found, pod might have shareProcessNamespace to true.")
 	}
-	if kubeletFound {
-		res.AddComment("The kubelet process was found, pod might have hostPID to true.")
+	if len(evidence) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("The container likely shares the host PID namespace, pod might have hostPID to true: %s.", strings.Join(evidence, ", ")))
 	}
-	res.AddContent([]interface{}{deviceNumber, pauseFound, kubeletFound})
 
 	return *res, nil
 }
 
+// hostEvidence returns the reasons to believe that the processes are the ones
+// of the host, none meaning that the namespace looks like a container one.
+func hostEvidence(processes []ps.Process) []string {
+	var evidence []string
+	daemons := map[string]bool{}
+	for _, p := range processes {
+		switch {
+		case p.Pid() == 1 && contains(HostInits, p.Executable()):
+			evidence = append(evidence, fmt.Sprintf("PID 1 is %s", p.Executable()))
+		case p.Pid() == 2 && p.Executable() == "kthreadd":
+			// kernel threads are only visible from the initial namespace
+			evidence = append(evidence, "kernel threads are visible")
+		case contains(hostDaemons, p.Executable()) && !daemons[p.Executable()]:
+			daemons[p.Executable()] = true
+			evidence = append(evidence, fmt.Sprintf("%s is running", p.Executable()))
+		}
+	}
+	if len(processes) > hostProcessesThreshold {
+		evidence = append(evidence, fmt.Sprintf("%d processes are visible", len(processes)))
+	}
+	return evidence
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
@@ -53,26 +112,13 @@ func NewPIDNamespaceBucket(_ bucket.Config) (*Bucket, error) {
 	return &Bucket{}, nil
 }
 
-func getPIDNamespaceInfo() (deviceNumber int, kubeletFound bool, pauseFound bool, err error) {
+func getPIDNamespaceDevice() (int, error) {
 	// Get device number indicator
 	file := "/proc/1/ns/pid"
 	// Use Lstat to not follow the symlink.
 	var info syscall.Stat_t
 	if err := syscall.Lstat(file, &info); err != nil {
-		return 0, false, false, err
+		return 0, err
 	}
-
-	deviceNumber = int(info.Dev)
-
-	processes, err := ps.Processes()
-	if err != nil {
-		return 0, false, false, err
-	}
-
-	for i := range processes {
-		kubeletFound = kubeletFound || processes[i].Executable() == "kubelet"
-		pauseFound = pauseFound || processes[i].Executable() == "pause"
-	}
-
-	return
+	return int(info.Dev), nil
 }
@@ -0,0 +1,42 @@
+package pidnamespace
+
+import (
+	"testing"
+
+	"github.com/mitchellh/go-ps"
+)
+
+type testProcess struct {
+	pid        int
+	executable string
+}
+
+func (p testProcess) Pid() int           { return p.pid }
+func (p testProcess) PPid() int          { return 0 }
+func (p testProcess) Executable() string { return p.executable }
+
+func TestHostEvidence(t *testing.T) {
+	many := make([]ps.Process, 0, hostProcessesThreshold+1)
+	for i := 0; i <= hostProcessesThreshold; i++ {
+		many = append(many, testProcess{i + 10, "worker"})
+	}
+
+	tests := []struct {
+		name      string
+		processes []ps.Process
+		want      int
+	}{
+		{"container", []ps.Process{testProcess{1, "tini"}, testProcess{7, "nginx"}}, 0},
+		{"shared pod namespace", []ps.Process{testProcess{1, "pause"}, testProcess{7, "nginx"}}, 0},
+		{"host init", []ps.Process{testProcess{1, "systemd"}, testProcess{2, "kthreadd"}}, 2},
+		{"host daemons", []ps.Process{testProcess{1, "bash"}, testProcess{40, "kubelet"}, testProcess{41, "kubelet"}, testProcess{42, "containerd"}}, 2},
+		{"many processes", many, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hostEvidence(tt.processes); len(got) != tt.want {
+				t.Errorf("hostEvidence() = %v, want %d evidence", got, tt.want)
+			}
+		})
+	}
+}
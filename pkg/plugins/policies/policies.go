@@ -0,0 +1,272 @@
+package policies
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	bucketName        = "policies"
+	bucketDescription = "Policies lists the pod security policies enforced in the namespace, from PSP, Pod Security Admission, Gatekeeper or Kyverno."
+
+	typePSA        = "PodSecurityAdmission"
+	typePSP        = "PodSecurityPolicy"
+	typeGatekeeper = "Gatekeeper"
+	typeKyverno    = "Kyverno"
+
+	podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+	pspGroupVersion        = "policy/v1beta1"
+	gatekeeperGroupVersion = "constraints.gatekeeper.sh/v1beta1"
+	kyvernoGroupVersion    = "kyverno.io/v1"
+)
+
+var bucketAliases = []string{"policy", "psp"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+type policy struct {
+	name         string
+	kind         string
+	restrictions []string
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+	res.AddComment(fmt.Sprintf("Checking policies applying to the %q namespace.", n.config.Namespace))
+
+	var policies []policy
+	// every engine is optional, errors are reported as comments so that one
+	// forbidden engine does not hide the others
+	collectors := []struct {
+		kind    string
+		collect func(ctx context.Context) ([]policy, error)
+	}{
+		{typePSA, n.podSecurityAdmission},
+		{typePSP, n.podSecurityPolicies},
+		{typeGatekeeper, n.gatekeeperConstraints},
+		{typeKyverno, n.kyvernoPolicies},
+	}
+	for _, c := range collectors {
+		p, err := c.collect(ctx)
+		if err != nil {
+			res.AddComment(fmt.Sprintf("Failed to retrieve %s policies: %s", c.kind, err))
+			continue
+		}
+		policies = append(policies, p...)
+	}
+
+	res.SetHeaders([]string{"name", "type", "restrictions"})
+	for _, p := range policies {
+		res.AddContent([]interface{}{p.name, p.kind, p.restrictions})
+	}
+	if len(policies) == 0 {
+		res.AddComment("No pod security policy was found, pods might be admitted without restrictions.")
+	}
+
+	return *res, nil
+}
+
+// podSecurityAdmission reads the Pod Security Admission labels of the
+// namespace, the in-tree replacement of PodSecurityPolicies.
+func (n Bucket) podSecurityAdmission(ctx context.Context) ([]policy, error) {
+	ns, err := n.config.Client.CoreV1().Namespaces().Get(ctx, n.config.Namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var restrictions []string
+	for key, value := range ns.Labels {
+		if strings.HasPrefix(key, podSecurityLabelPrefix) {
+			restrictions = append(restrictions, strings.TrimPrefix(key, podSecurityLabelPrefix)+"="+value)
+		}
+	}
+	if len(restrictions) == 0 {
+		return nil, nil
+	}
+	sort.Strings(restrictions)
+	return []policy{{name: ns.Name, kind: typePSA, restrictions: restrictions}}, nil
+}
+
+// podSecurityPolicies lists the PSPs, the API was removed in 1.25.
+func (n Bucket) podSecurityPolicies(ctx context.Context) ([]policy, error) {
+	items, err := n.listIfServed(ctx, pspGroupVersion, "podsecuritypolicies", "")
+	if err != nil || items == nil {
+		return nil, err
+	}
+	var policies []policy
+	for _, item := range items.Items {
+		var restrictions []string
+		for _, field := range []string{"privileged", "hostNetwork", "hostPID", "hostIPC", "allowPrivilegeEscalation"} {
+			if value, found, _ := unstructured.NestedBool(item.Object, "spec", field); found {
+				restrictions = append(restrictions, fmt.Sprintf("%s=%t", field, value))
+			}
+		}
+		if rule, found, _ := unstructured.NestedString(item.Object, "spec", "runAsUser", "rule"); found {
+			restrictions = append(restrictions, "runAsUser="+rule)
+		}
+		if caps, found, _ := unstructured.NestedStringSlice(item.Object, "spec", "allowedCapabilities"); found {
+			restrictions = append(restrictions, fmt.Sprintf("allowedCapabilities=%v", caps))
+		}
+		policies = append(policies, policy{name: item.GetName(), kind: typePSP, restrictions: restrictions})
+	}
+	return policies, nil
+}
+
+// gatekeeperConstraints lists the constraints of every constraint kind
+// created from the installed constraint templates.
+func (n Bucket) gatekeeperConstraints(ctx context.Context) ([]policy, error) {
+	resources, err := n.config.Client.Discovery().ServerResourcesForGroupVersion(gatekeeperGroupVersion)
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var policies []policy
+	for _, r := range resources.APIResources {
+		if strings.Contains(r.Name, "/") {
+			// skip subresources like status
+			continue
+		}
+		items, err := n.list(ctx, gatekeeperGroupVersion, r.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items.Items {
+			if !gatekeeperMatchesNamespace(item, n.config.Namespace) {
+				continue
+			}
+			action, _, _ := unstructured.NestedString(item.Object, "spec", "enforcementAction")
+			if action == "" {
+				action = "deny"
+			}
+			policies = append(policies, policy{
+				name:         item.GetName(),
+				kind:         typeGatekeeper,
+				restrictions: []string{"kind=" + r.Kind, "enforcementAction=" + action},
+			})
+		}
+	}
+	return policies, nil
+}
+
+func gatekeeperMatchesNamespace(constraint unstructured.Unstructured, namespace string) bool {
+	excluded, _, _ := unstructured.NestedStringSlice(constraint.Object, "spec", "match", "excludedNamespaces")
+	for _, ns := range excluded {
+		if ns == namespace {
+			return false
+		}
+	}
+	namespaces, found, _ := unstructured.NestedStringSlice(constraint.Object, "spec", "match", "namespaces")
+	if !found || len(namespaces) == 0 {
+		return true
+	}
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// kyvernoPolicies lists the cluster policies and the policies of the
+// namespace.
+func (n Bucket) kyvernoPolicies(ctx context.Context) ([]policy, error) {
+	var policies []policy
+	for _, namespace := range []string{"", n.config.Namespace} {
+		resource := "clusterpolicies"
+		if namespace != "" {
+			resource = "policies"
+		}
+		items, err := n.listIfServed(ctx, kyvernoGroupVersion, resource, namespace)
+		if err != nil || items == nil {
+			return policies, err
+		}
+		for _, item := range items.Items {
+			action, _, _ := unstructured.NestedString(item.Object, "spec", "validationFailureAction")
+			if action == "" {
+				action = "Audit"
+			}
+			restrictions := []string{"validationFailureAction=" + action}
+			rules, _, _ := unstructured.NestedSlice(item.Object, "spec", "rules")
+			for _, rule := range rules {
+				if r, ok := rule.(map[string]interface{}); ok {
+					if name, ok := r["name"].(string); ok {
+						restrictions = append(restrictions, "rule="+name)
+					}
+				}
+			}
+			policies = append(policies, policy{name: item.GetName(), kind: typeKyverno, restrictions: restrictions})
+		}
+	}
+	return policies, nil
+}
+
+// listIfServed lists the resource only if the API server serves it, it
+// returns nil items and no error otherwise.
+func (n Bucket) listIfServed(ctx context.Context, groupVersion string, resource string, namespace string) (*unstructured.UnstructuredList, error) {
+	resources, err := n.config.Client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if kerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return n.list(ctx, groupVersion, resource, namespace)
+		}
+	}
+	return nil, nil
+}
+
+// list uses raw requests to list custom resources, the typed client only
+// knows about the core APIs.
+func (n Bucket) list(ctx context.Context, groupVersion string, resource string, namespace string) (*unstructured.UnstructuredList, error) {
+	path := "/apis/" + groupVersion
+	if namespace != "" {
+		path += "/namespaces/" + namespace
+	}
+	path += "/" + resource
+
+	raw, err := n.config.Client.Discovery().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{}
+	if err := list.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewPoliciesBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewPoliciesBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
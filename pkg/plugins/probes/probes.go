@@ -0,0 +1,162 @@
+package probes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	bucketName        = "probes"
+	bucketDescription = "Probes lists the lifecycle hooks and probes of the pod containers, reporting commands and endpoints they use."
+
+	handlerExec = "exec"
+	handlerHTTP = "httpGet"
+)
+
+var bucketAliases = []string{"probe", "hooks", "lifecycle"}
+
+// sensitivePaths are prefixes of debug and administration endpoints.
+var sensitivePaths = []string{"/debug", "/admin", "/actuator", "/env", "/pprof", "/console", "/metrics", "/config"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+type handler struct {
+	container string
+	kind      string
+	handler   string
+	target    string
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	pod, err := automaticontext.CurrentPod(ctx, n.config.Client, n.config.Namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	var handlers []handler
+	containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	for _, c := range containers {
+		if c.Lifecycle != nil {
+			handlers = appendLifecycleHandler(handlers, c.Name, "postStart", c.Lifecycle.PostStart)
+			handlers = appendLifecycleHandler(handlers, c.Name, "preStop", c.Lifecycle.PreStop)
+		}
+		handlers = appendProbe(handlers, c.Name, "livenessProbe", c.LivenessProbe)
+		handlers = appendProbe(handlers, c.Name, "readinessProbe", c.ReadinessProbe)
+		handlers = appendProbe(handlers, c.Name, "startupProbe", c.StartupProbe)
+	}
+
+	if len(handlers) == 0 {
+		res.AddComment("The pod has no lifecycle hook or probe.")
+		return *res, nil
+	}
+
+	res.SetHeaders([]string{"container", "type", "handler", "target"})
+	var execs, sensitives int
+	for _, h := range handlers {
+		res.AddContent([]interface{}{h.container, h.kind, h.handler, h.target})
+		switch {
+		case h.handler == handlerExec:
+			execs++
+		case h.handler == handlerHTTP && isSensitive(h.target):
+			sensitives++
+		}
+	}
+
+	if execs > 0 {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment(fmt.Sprintf("%d hooks or probes execute commands in the containers, binaries and scripts they run could be tampered with to gain execution.", execs))
+	}
+	if sensitives > 0 {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment(fmt.Sprintf("%d HTTP hooks or probes target debug or administration endpoints, they might be reachable by other workloads.", sensitives))
+	}
+
+	return *res, nil
+}
+
+func appendLifecycleHandler(handlers []handler, container string, kind string, h *v1.LifecycleHandler) []handler {
+	if h == nil {
+		return handlers
+	}
+	switch {
+	case h.Exec != nil:
+		return append(handlers, handler{container, kind, handlerExec, strings.Join(h.Exec.Command, " ")})
+	case h.HTTPGet != nil:
+		return append(handlers, handler{container, kind, handlerHTTP, httpTarget(h.HTTPGet)})
+	case h.TCPSocket != nil:
+		return append(handlers, handler{container, kind, "tcpSocket", net.JoinHostPort(h.TCPSocket.Host, h.TCPSocket.Port.String())})
+	case h.Sleep != nil:
+		return append(handlers, handler{container, kind, "sleep", fmt.Sprintf("%ds", h.Sleep.Seconds)})
+	default:
+		return handlers
+	}
+}
+
+func appendProbe(handlers []handler, container string, kind string, p *v1.Probe) []handler {
+	if p == nil {
+		return handlers
+	}
+	switch {
+	case p.Exec != nil:
+		return append(handlers, handler{container, kind, handlerExec, strings.Join(p.Exec.Command, " ")})
+	case p.HTTPGet != nil:
+		return append(handlers, handler{container, kind, handlerHTTP, httpTarget(p.HTTPGet)})
+	case p.TCPSocket != nil:
+		return append(handlers, handler{container, kind, "tcpSocket", net.JoinHostPort(p.TCPSocket.Host, p.TCPSocket.Port.String())})
+	case p.GRPC != nil:
+		return append(handlers, handler{container, kind, "grpc", fmt.Sprint(p.GRPC.Port)})
+	default:
+		return handlers
+	}
+}
+
+// httpTarget formats the action as an URL, an empty host means the pod IP.
+func httpTarget(a *v1.HTTPGetAction) string {
+	scheme := strings.ToLower(string(a.Scheme))
+	if scheme == "" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(a.Host, a.Port.String()), a.Path)
+}
+
+func isSensitive(target string) bool {
+	for _, path := range sensitivePaths {
+		if strings.Contains(target, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewProbesBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewProbesBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
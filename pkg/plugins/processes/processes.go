@@ -1,6 +1,7 @@
 package processes
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/mitchellh/go-ps"
@@ -16,7 +17,7 @@ var bucketAliases = []string{"process", "ps"}
 
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
 
 	processes, err := ps.Processes()
@@ -0,0 +1,117 @@
+package procroot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/mitchellh/go-ps"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "procroot"
+	bucketDescription = "ProcRoot checks if the root and cwd of processes in other mount namespaces are traversable through /proc."
+)
+
+var bucketAliases = []string{"procroots", "pr"}
+
+type Bucket struct{}
+
+type foreignProcess struct {
+	pid             int
+	name            string
+	rootTraversable bool
+	cwdTraversable  bool
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	selfNS, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to read own mount namespace: %w", err)
+	}
+
+	processes, err := ps.Processes()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	var foreigns []foreignProcess
+	// processes whose mount namespace can't be read, usually because of the
+	// ptrace access mode, can't be compared and are only counted
+	unknown := 0
+	for _, p := range processes {
+		procPath := "/proc/" + strconv.Itoa(p.Pid())
+		ns, err := os.Readlink(procPath + "/ns/mnt")
+		if err != nil {
+			unknown++
+			continue
+		}
+		if ns == selfNS {
+			continue
+		}
+		foreigns = append(foreigns, foreignProcess{
+			pid:             p.Pid(),
+			name:            p.Executable(),
+			rootTraversable: isTraversable(procPath + "/root"),
+			cwdTraversable:  isTraversable(procPath + "/cwd"),
+		})
+	}
+
+	if unknown > 0 {
+		res.AddComment(fmt.Sprintf("The mount namespace of %d processes could not be read, they were skipped.", unknown))
+	}
+	if len(foreigns) == 0 {
+		res.AddComment("No process from another mount namespace is visible, the PID namespace does not seem to be shared.")
+		return *res, nil
+	}
+
+	res.SetHeaders([]string{"pid", "name", "rootTraversable", "cwdTraversable"})
+	traversable := 0
+	for _, p := range foreigns {
+		res.AddContent([]interface{}{p.pid, p.name, p.rootTraversable, p.cwdTraversable})
+		if p.rootTraversable || p.cwdTraversable {
+			traversable++
+		}
+	}
+
+	res.AddComment(fmt.Sprintf("%d processes from other mount namespaces are visible, %d of them are traversable.", len(foreigns), traversable))
+	if traversable > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment("The filesystems of other containers or of the host can be accessed via /proc/<pid>/root.")
+	}
+
+	return *res, nil
+}
+
+// isTraversable only lists the first entry of the directory, it never reads
+// files contents.
+func isTraversable(path string) bool {
+	dir, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer dir.Close()
+	_, err = dir.Readdirnames(1)
+	return err == nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewProcRootBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewProcRootBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,145 @@
+package releaseagent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/mount"
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	bucketName        = "releaseagent"
+	bucketDescription = "ReleaseAgent checks the preconditions of the cgroup v1 release_agent container escape without triggering it."
+
+	notifyOnReleaseFile = "notify_on_release"
+	releaseAgentFile    = "release_agent"
+)
+
+var bucketAliases = []string{"release", "ra"}
+
+type Bucket struct{}
+
+// Hierarchy is the result of the preconditions checks on a mounted cgroup v1
+// hierarchy.
+type Hierarchy struct {
+	Path            string
+	Writable        bool
+	CreateChild     bool
+	NotifyOnRelease bool
+	ReleaseAgent    bool
+}
+
+// Exploitable returns true if all the preconditions of the escape are met.
+func (h Hierarchy) Exploitable() bool {
+	return h.Writable && h.CreateChild && h.NotifyOnRelease && h.ReleaseAgent
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	mnts, err := mount.Mounts()
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	var hierarchies []Hierarchy
+	for _, mnt := range mnts {
+		if mnt.Filesystem != "cgroup" {
+			continue
+		}
+		hierarchies = append(hierarchies, checkHierarchy(mnt))
+	}
+
+	if len(hierarchies) == 0 {
+		res.AddComment("No cgroup v1 hierarchy is mounted, the release_agent escape does not apply as is.")
+		return *res, nil
+	}
+
+	res.SetHeaders([]string{"hierarchy", "writable", "createChild", "notifyOnRelease", "releaseAgent"})
+	exploitable := false
+	for _, h := range hierarchies {
+		res.AddContent([]interface{}{h.Path, h.Writable, h.CreateChild, h.NotifyOnRelease, h.ReleaseAgent})
+		exploitable = exploitable || h.Exploitable()
+	}
+
+	if exploitable {
+		res.RaiseSeverity(bucket.SeverityCritical)
+		res.AddComment("All the preconditions are met on at least one hierarchy, the release_agent escape is likely exploitable.")
+	} else {
+		res.AddComment("No hierarchy meets all the preconditions, the release_agent escape does not seem exploitable with the current mounts.")
+	}
+	res.AddComment("Checks are non-triggering: values are written back unchanged and created cgroups are removed.")
+
+	return *res, nil
+}
+
+// checkHierarchy verifies every precondition of the escape in order, a
+// precondition is only checked if the previous one was met.
+func checkHierarchy(mnt mount.Mount) Hierarchy {
+	h := Hierarchy{Path: mnt.Path}
+
+	h.Writable = mnt.ReadWrite() && unix.Access(mnt.Path, unix.W_OK) == nil
+	if !h.Writable {
+		return h
+	}
+
+	// the child is created only to write notify_on_release, it is empty of
+	// any process so the release agent can't be triggered
+	child := filepath.Join(mnt.Path, "kdigger-"+rand.String(5))
+	if err := os.Mkdir(child, 0o755); err != nil {
+		return h
+	}
+	defer os.Remove(child)
+	h.CreateChild = true
+
+	h.NotifyOnRelease = RewriteFile(filepath.Join(child, notifyOnReleaseFile)) == nil
+	// release_agent only exists at the root of the hierarchy
+	h.ReleaseAgent = RewriteFile(filepath.Join(mnt.Path, releaseAgentFile)) == nil
+
+	return h
+}
+
+// RewriteFile writes back the current content of a file to check if it can be
+// written to without modifying anything.
+func RewriteFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(content) == 0 {
+		// an empty write would not reach the kernel handler
+		content = []byte("\n")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(content)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewReleaseAgentBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: false,
+	})
+}
+
+func NewReleaseAgentBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
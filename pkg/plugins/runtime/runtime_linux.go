@@ -1,15 +1,199 @@
 package runtime
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/genuinetools/bpfd/proc"
 	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/mount"
+	"golang.org/x/sys/unix"
 )
 
-func (n Bucket) Run() (bucket.Results, error) {
-	runtime := proc.GetContainerRuntime(0, 0)
+const (
+	runtimeDocker     = "docker"
+	runtimeContainerd = "containerd"
+	runtimeCRIO       = "cri-o"
+	runtimePodman     = "podman"
+	runtimeLXC        = "lxc"
+	runtimeGVisor     = "gvisor"
+	runtimeKata       = "kata"
+)
+
+// gVisorKernelVersion is the version returned by uname in gVisor sandboxes.
+const gVisorKernelVersion = "#1 SMP Sun Jan 10 15:06:54 PST 2016"
+
+// sandboxRuntimes isolate the container in a sandbox, they are usually
+// driven by another runtime whose clues remain visible so they take
+// precedence in the guess.
+var sandboxRuntimes = []string{runtimeGVisor, runtimeKata}
+
+// cgroupPatterns identify the runtime from the cgroup path of the first
+// process, the first matching one wins.
+var cgroupPatterns = []struct {
+	pattern string
+	runtime string
+}{
+	{"cri-containerd", runtimeContainerd},
+	{"crio-", runtimeCRIO},
+	{"libpod", runtimePodman},
+	{"docker", runtimeDocker},
+	{"containerd", runtimeContainerd},
+	{"lxc", runtimeLXC},
+}
+
+// overlayPatterns identify the runtime from the storage path of the layers of
+// the root overlay filesystem.
+var overlayPatterns = []struct {
+	pattern string
+	runtime string
+}{
+	{"/var/lib/docker/", runtimeDocker},
+	{"io.containerd.snapshotter", runtimeContainerd},
+	{"/var/lib/containers/storage/", runtimeCRIO},
+}
+
+// clue is a signal betraying the presence of a runtime.
+type clue struct {
+	signal  string
+	runtime string
+	detail  string
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
-	res.AddComment(fmt.Sprintf("The container runtime seems to be %s.", runtime))
+
+	var clues []clue
+	clues = append(clues, fileClues()...)
+	clues = append(clues, cgroupClues()...)
+	clues = append(clues, mountClues()...)
+	clues = append(clues, gVisorClues()...)
+	// the heuristics from amicontained, they make no distinction between
+	// Docker and containerd
+	if r := string(proc.GetContainerRuntime(0, 0)); r != string(proc.RuntimeNotFound) && r != string(proc.RuntimeKubernetes) {
+		clues = append(clues, clue{"amicontained", r, "bpfd heuristics"})
+	}
+
+	res.SetHeaders([]string{"signal", "runtime", "detail"})
+	for _, c := range clues {
+		res.AddContent([]interface{}{c.signal, c.runtime, c.detail})
+	}
+
+	guess := bestGuess(clues)
+	if guess == "" {
+		res.AddComment("No clue about the container runtime was found.")
+		return *res, nil
+	}
+	var evidence []string
+	for _, c := range clues {
+		if c.runtime == guess {
+			evidence = append(evidence, c.signal)
+		}
+	}
+	res.AddComment(fmt.Sprintf("The container runtime seems to be %s, according to %s.", guess, strings.Join(evidence, ", ")))
 	return *res, nil
 }
+
+// bestGuess returns the runtime with the most clues, a sandbox runtime wins
+// over the others, ties are broken by the order of the clues.
+func bestGuess(clues []clue) string {
+	for _, sandbox := range sandboxRuntimes {
+		for _, c := range clues {
+			if c.runtime == sandbox {
+				return sandbox
+			}
+		}
+	}
+	counts := make(map[string]int)
+	guess := ""
+	for _, c := range clues {
+		counts[c.runtime]++
+		if counts[c.runtime] > counts[guess] {
+			guess = c.runtime
+		}
+	}
+	return guess
+}
+
+// fileClues looks for the files that runtimes create at the root of the
+// container.
+func fileClues() []clue {
+	var clues []clue
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		clues = append(clues, clue{"/.dockerenv", runtimeDocker, "file exists"})
+	}
+	if content, err := os.ReadFile("/run/.containerenv"); err == nil {
+		// podman fills it with the engine, CRI-O leaves it empty
+		if bytes.Contains(content, []byte("podman")) {
+			clues = append(clues, clue{"/run/.containerenv", runtimePodman, "engine is podman"})
+		} else {
+			clues = append(clues, clue{"/run/.containerenv", runtimeCRIO, "file exists"})
+		}
+	}
+	return clues
+}
+
+func cgroupClues() []clue {
+	content, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		for _, p := range cgroupPatterns {
+			if strings.Contains(line, p.pattern) {
+				return []clue{{"/proc/1/cgroup", p.runtime, line}}
+			}
+		}
+	}
+	return nil
+}
+
+// mountClues looks at the layers of the root overlay and at the shared
+// directories of Kata virtual machines.
+func mountClues() []clue {
+	mounts, err := mount.MountInfos()
+	if err != nil {
+		return nil
+	}
+	var clues []clue
+	var kataShare *mount.MountInfo
+	for i, m := range mounts {
+		if m.MountPoint == "/" && m.FSType == "overlay" {
+			for _, p := range overlayPatterns {
+				if strings.Contains(m.SuperOptions, p.pattern) {
+					clues = append(clues, clue{"root overlay", p.runtime, "layers in " + p.pattern})
+					break
+				}
+			}
+		}
+		if m.Source == "kataShared" && kataShare == nil {
+			kataShare = &mounts[i]
+		}
+	}
+	if kataShare != nil {
+		clues = append(clues, clue{"mountinfo", runtimeKata, fmt.Sprintf("%s is a %s share", kataShare.MountPoint, kataShare.FSType)})
+	}
+	return clues
+}
+
+// gVisorClues reads the kernel ring buffer, the sandbox of gVisor prints its
+// own messages there, and checks the emulated kernel version.
+func gVisorClues() []clue {
+	var clues []clue
+	buf := make([]byte, 1<<16)
+	n, err := unix.Klogctl(unix.SYSLOG_ACTION_READ_ALL, buf)
+	if err == nil && bytes.Contains(buf[:n], []byte("gVisor")) {
+		clues = append(clues, clue{"dmesg", runtimeGVisor, "gVisor messages in the kernel log"})
+	}
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err == nil {
+		// gVisor emulates a constant kernel version
+		if version := unix.ByteSliceToString(uname.Version[:]); version == gVisorKernelVersion {
+			clues = append(clues, clue{"uname", runtimeGVisor, "kernel version is " + version})
+		}
+	}
+	return clues
+}
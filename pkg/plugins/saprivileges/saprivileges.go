@@ -0,0 +1,336 @@
+package saprivileges
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	bucketName        = "saprivileges"
+	bucketDescription = "SAPrivileges ranks the service accounts of the cluster by the privileges of the roles they are bound to."
+
+	pageSize = 500
+
+	clusterAdminRole = "cluster-admin"
+)
+
+var bucketAliases = []string{"saprivs", "sarank"}
+
+// privilege scores, a rule is scored on its most dangerous permission
+const (
+	scoreWildcard        = 100
+	scoreEscalation      = 80
+	scorePartialWildcard = 60
+	scoreSecrets         = 50
+	scoreWorkloads       = 40
+)
+
+// knownRoles are default cluster roles that are scored even when cluster
+// roles can't be read.
+var knownRoles = map[string][]rbacv1.PolicyRule{
+	clusterAdminRole: {{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+type privilege struct {
+	serviceAccount string
+	namespace      string
+	role           string
+	score          int
+}
+
+// roleRef identifies a Role or a ClusterRole, namespace is empty for the
+// latter.
+type roleRef struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	// cluster wide listing is attempted first, forbidden errors scope the
+	// enumeration to the current namespace
+	namespace := metav1.NamespaceAll
+	serviceAccounts, err := n.serviceAccounts(ctx, namespace)
+	if kerrors.IsForbidden(err) {
+		namespace = n.config.Namespace
+		res.AddComment(fmt.Sprintf("Listing service accounts cluster-wide is forbidden, scoping to the %q namespace.", namespace))
+		serviceAccounts, err = n.serviceAccounts(ctx, namespace)
+	}
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	rules, err := n.rules(ctx, namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	bindings, err := n.bindings(ctx, namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	// keep the highest scored role of every service account
+	ranking := make(map[string]*privilege)
+	for _, b := range bindings {
+		ref := roleRef{kind: b.RoleRef.Kind, name: b.RoleRef.Name}
+		if ref.kind == "Role" {
+			ref.namespace = b.Namespace
+		}
+		roleRules, ok := rules[ref]
+		if !ok && ref.kind == "ClusterRole" {
+			roleRules, ok = knownRoles[ref.name]
+		}
+		if !ok {
+			continue
+		}
+		// a RoleBinding grants the permissions in its namespace only, even
+		// when it references a ClusterRole
+		score := privilegeScore(roleRules, b.Namespace == "")
+		for _, s := range b.Subjects {
+			if s.Kind != rbacv1.ServiceAccountKind {
+				continue
+			}
+			ns := s.Namespace
+			if ns == "" {
+				ns = b.Namespace
+			}
+			key := ns + "/" + s.Name
+			if _, exists := serviceAccounts[key]; !exists {
+				continue
+			}
+			if p, exists := ranking[key]; exists && p.score >= score {
+				continue
+			}
+			ranking[key] = &privilege{serviceAccount: s.Name, namespace: ns, role: ref.kind + "/" + ref.name, score: score}
+		}
+	}
+
+	privileges := make([]privilege, 0, len(ranking))
+	for _, p := range ranking {
+		if p.score > 0 {
+			privileges = append(privileges, *p)
+		}
+	}
+	sort.Slice(privileges, func(i, j int) bool {
+		if privileges[i].score != privileges[j].score {
+			return privileges[i].score > privileges[j].score
+		}
+		if privileges[i].namespace != privileges[j].namespace {
+			return privileges[i].namespace < privileges[j].namespace
+		}
+		return privileges[i].serviceAccount < privileges[j].serviceAccount
+	})
+
+	res.SetHeaders([]string{"serviceAccount", "namespace", "highestRole", "score"})
+	for _, p := range privileges {
+		res.AddContent([]interface{}{p.serviceAccount, p.namespace, p.role, p.score})
+	}
+	res.AddComment(fmt.Sprintf("%d service accounts were enumerated, %d hold sensitive privileges.", len(serviceAccounts), len(privileges)))
+
+	if len(privileges) > 0 {
+		switch {
+		case privileges[0].score >= scoreWildcard:
+			res.RaiseSeverity(bucket.SeverityHigh)
+			res.AddComment("Some service accounts are bound to cluster-admin or wildcard roles, their tokens are high value targets.")
+		case privileges[0].score >= scoreSecrets:
+			res.RaiseSeverity(bucket.SeverityMedium)
+		default:
+			res.RaiseSeverity(bucket.SeverityLow)
+		}
+	}
+
+	return *res, nil
+}
+
+// privilegeScore returns the score of the most dangerous rule, the score is
+// halved for permissions granted in a single namespace.
+func privilegeScore(rules []rbacv1.PolicyRule, clusterWide bool) int {
+	score := 0
+	for _, r := range rules {
+		score = max(score, ruleScore(r))
+	}
+	if !clusterWide {
+		score /= 2
+	}
+	return score
+}
+
+func ruleScore(r rbacv1.PolicyRule) int {
+	// non resource URLs rules only grant access to endpoints like /metrics
+	if len(r.Resources) == 0 {
+		return 0
+	}
+	wildcardVerbs := contains(r.Verbs, rbacv1.VerbAll)
+	wildcardResources := contains(r.Resources, rbacv1.ResourceAll)
+	wildcardGroups := contains(r.APIGroups, rbacv1.APIGroupAll)
+
+	switch {
+	case wildcardVerbs && wildcardResources && wildcardGroups:
+		return scoreWildcard
+	case hasAny(r.Verbs, "escalate", "bind", "impersonate"):
+		return scoreEscalation
+	case wildcardVerbs || wildcardResources:
+		return scorePartialWildcard
+	case contains(r.Resources, "secrets") && hasAny(r.Verbs, "get", "list", "watch"):
+		return scoreSecrets
+	case hasAny(r.Resources, "pods", "pods/exec", "deployments", "daemonsets", "jobs", "cronjobs") && hasAny(r.Verbs, "create", "update", "patch"):
+		return scoreWorkloads
+	}
+	return 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAny(values []string, candidates ...string) bool {
+	for _, c := range candidates {
+		if contains(values, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// paginate calls list until the API server stops returning a continue
+// token.
+func paginate(list func(opts metav1.ListOptions) (string, error)) error {
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		next, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		opts.Continue = next
+	}
+}
+
+func (n Bucket) serviceAccounts(ctx context.Context, namespace string) (map[string]struct{}, error) {
+	serviceAccounts := make(map[string]struct{})
+	err := paginate(func(opts metav1.ListOptions) (string, error) {
+		list, err := n.config.Client.CoreV1().ServiceAccounts(namespace).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, sa := range list.Items {
+			serviceAccounts[sa.Namespace+"/"+sa.Name] = struct{}{}
+		}
+		return list.Continue, nil
+	})
+	return serviceAccounts, err
+}
+
+// rules returns the rules of the roles and cluster roles, forbidden errors
+// are ignored and only the known roles are scored.
+func (n Bucket) rules(ctx context.Context, namespace string) (map[roleRef][]rbacv1.PolicyRule, error) {
+	rules := make(map[roleRef][]rbacv1.PolicyRule)
+	err := paginate(func(opts metav1.ListOptions) (string, error) {
+		list, err := n.config.Client.RbacV1().ClusterRoles().List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, r := range list.Items {
+			rules[roleRef{kind: "ClusterRole", name: r.Name}] = r.Rules
+		}
+		return list.Continue, nil
+	})
+	if err != nil && !kerrors.IsForbidden(err) {
+		return nil, err
+	}
+	err = paginate(func(opts metav1.ListOptions) (string, error) {
+		list, err := n.config.Client.RbacV1().Roles(namespace).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, r := range list.Items {
+			rules[roleRef{kind: "Role", namespace: r.Namespace, name: r.Name}] = r.Rules
+		}
+		return list.Continue, nil
+	})
+	if err != nil && !kerrors.IsForbidden(err) {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// binding is the common part of RoleBindings and ClusterRoleBindings,
+// namespace is empty for the latter.
+type binding struct {
+	Namespace string
+	RoleRef   rbacv1.RoleRef
+	Subjects  []rbacv1.Subject
+}
+
+func (n Bucket) bindings(ctx context.Context, namespace string) ([]binding, error) {
+	var bindings []binding
+	err := paginate(func(opts metav1.ListOptions) (string, error) {
+		list, err := n.config.Client.RbacV1().ClusterRoleBindings().List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, b := range list.Items {
+			bindings = append(bindings, binding{RoleRef: b.RoleRef, Subjects: b.Subjects})
+		}
+		return list.Continue, nil
+	})
+	if err != nil && !kerrors.IsForbidden(err) {
+		return nil, err
+	}
+	err = paginate(func(opts metav1.ListOptions) (string, error) {
+		list, err := n.config.Client.RbacV1().RoleBindings(namespace).List(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		for _, b := range list.Items {
+			bindings = append(bindings, binding{Namespace: b.Namespace, RoleRef: b.RoleRef, Subjects: b.Subjects})
+		}
+		return list.Continue, nil
+	})
+	if err != nil && !kerrors.IsForbidden(err) {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewSAPrivilegesBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewSAPrivilegesBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package saprivileges
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestPrivilegeScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []rbacv1.PolicyRule
+		clusterWide bool
+		want        int
+	}{
+		{
+			name:        "cluster-admin",
+			rules:       knownRoles[clusterAdminRole],
+			clusterWide: true,
+			want:        scoreWildcard,
+		},
+		{
+			name:        "cluster-admin in a namespace",
+			rules:       knownRoles[clusterAdminRole],
+			clusterWide: false,
+			want:        scoreWildcard / 2,
+		},
+		{
+			name: "escalate",
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, Verbs: []string{"escalate"}},
+			},
+			clusterWide: true,
+			want:        scoreEscalation,
+		},
+		{
+			name: "wildcard verbs on a resource",
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"*"}},
+			},
+			clusterWide: true,
+			want:        scorePartialWildcard,
+		},
+		{
+			name: "highest rule wins",
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}},
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"create"}},
+			},
+			clusterWide: true,
+			want:        scoreSecrets,
+		},
+		{
+			name: "non resource URLs",
+			rules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"*"}, Verbs: []string{"*"}},
+			},
+			clusterWide: true,
+			want:        0,
+		},
+		{
+			name:        "no rules",
+			rules:       nil,
+			clusterWide: true,
+			want:        0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := privilegeScore(tt.rules, tt.clusterWide); got != tt.want {
+				t.Errorf("privilegeScore() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
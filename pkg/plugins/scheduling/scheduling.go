@@ -0,0 +1,211 @@
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	bucketName        = "scheduling"
+	bucketDescription = "Scheduling reports the affinity, anti-affinity and topology spread constraints of the pod and flags the ones targeting sensitive nodes or pods."
+)
+
+var bucketAliases = []string{"affinity", "topology"}
+
+// sensitiveKeys are node or pod labels identifying control plane components.
+var sensitiveKeys = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+	"node-role.kubernetes.io/etcd",
+}
+
+var sensitiveComponents = []string{"kube-apiserver", "etcd", "kube-controller-manager", "kube-scheduler"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+// rule is a scheduling constraint, sensitive when it attracts the pod towards
+// control plane nodes or components.
+type rule struct {
+	kind      string
+	summary   string
+	sensitive bool
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	pod, err := automaticontext.CurrentPod(ctx, n.config.Client, n.config.Namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	var rules []rule
+	// the keys are sorted to keep the order of the rows stable
+	keys := make([]string, 0, len(pod.Spec.NodeSelector))
+	for key := range pod.Spec.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		rules = append(rules, rule{"nodeSelector", key + "=" + pod.Spec.NodeSelector[key], contains(sensitiveKeys, key)})
+	}
+	if a := pod.Spec.Affinity; a != nil {
+		rules = append(rules, nodeAffinityRules(a.NodeAffinity)...)
+		if a.PodAffinity != nil {
+			rules = append(rules, podAffinityRules("podAffinity", a.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution, a.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution)...)
+		}
+		if a.PodAntiAffinity != nil {
+			rules = append(rules, podAffinityRules("podAntiAffinity", a.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, a.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)...)
+		}
+	}
+	for _, c := range pod.Spec.TopologySpreadConstraints {
+		rules = append(rules, rule{
+			"topologySpread",
+			fmt.Sprintf("topologyKey=%s maxSkew=%d whenUnsatisfiable=%s selector=%s", c.TopologyKey, c.MaxSkew, c.WhenUnsatisfiable, metav1.FormatLabelSelector(c.LabelSelector)),
+			selectorSensitive(c.LabelSelector, false),
+		})
+	}
+
+	if len(rules) == 0 {
+		res.AddComment("The pod has no scheduling constraint.")
+		return *res, nil
+	}
+
+	res.SetHeaders([]string{"type", "rule", "sensitive"})
+	var sensitives []string
+	for _, r := range rules {
+		if r.sensitive {
+			sensitives = append(sensitives, r.summary)
+		}
+		res.AddContent([]interface{}{r.kind, r.summary, r.sensitive})
+	}
+
+	if len(sensitives) > 0 {
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("Scheduling rules target control plane nodes or components, the pod might be co-located with sensitive workloads: %v.", sensitives))
+	}
+
+	return *res, nil
+}
+
+func nodeAffinityRules(a *v1.NodeAffinity) []rule {
+	if a == nil {
+		return nil
+	}
+	var rules []rule
+	if a.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		for _, term := range a.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			rules = append(rules, nodeSelectorTerm("nodeAffinity (required)", term))
+		}
+	}
+	for _, term := range a.PreferredDuringSchedulingIgnoredDuringExecution {
+		rules = append(rules, nodeSelectorTerm(fmt.Sprintf("nodeAffinity (preferred, weight %d)", term.Weight), term.Preference))
+	}
+	return rules
+}
+
+// nodeSelectorTerm is sensitive if one of its expressions requires a control
+// plane label, DoesNotExist and NotIn keep the pod away from these nodes.
+func nodeSelectorTerm(kind string, term v1.NodeSelectorTerm) rule {
+	var requirements []string
+	sensitive := false
+	for _, r := range term.MatchExpressions {
+		requirements = append(requirements, fmt.Sprintf("%s %s %v", r.Key, r.Operator, r.Values))
+		if contains(sensitiveKeys, r.Key) && (r.Operator == v1.NodeSelectorOpIn || r.Operator == v1.NodeSelectorOpExists) {
+			sensitive = true
+		}
+	}
+	for _, r := range term.MatchFields {
+		requirements = append(requirements, fmt.Sprintf("%s %s %v", r.Key, r.Operator, r.Values))
+	}
+	return rule{kind, strings.Join(requirements, ", "), sensitive}
+}
+
+func podAffinityRules(kind string, required []v1.PodAffinityTerm, preferred []v1.WeightedPodAffinityTerm) []rule {
+	var rules []rule
+	// anti affinity rules are never sensitive since they keep the pod away
+	attracts := kind == "podAffinity"
+	for _, term := range required {
+		rules = append(rules, podAffinityTerm(kind+" (required)", term, attracts))
+	}
+	for _, term := range preferred {
+		rules = append(rules, podAffinityTerm(fmt.Sprintf("%s (preferred, weight %d)", kind, term.Weight), term.PodAffinityTerm, attracts))
+	}
+	return rules
+}
+
+func podAffinityTerm(kind string, term v1.PodAffinityTerm, attracts bool) rule {
+	summary := fmt.Sprintf("topologyKey=%s selector=%s", term.TopologyKey, metav1.FormatLabelSelector(term.LabelSelector))
+	if len(term.Namespaces) > 0 {
+		summary += fmt.Sprintf(" namespaces=%v", term.Namespaces)
+	}
+	return rule{kind, summary, attracts && selectorSensitive(term.LabelSelector, true)}
+}
+
+// selectorSensitive reports if the selector requires a control plane label,
+// or a control plane component as a label value if components is set. Only
+// the In and Exists operators select these pods.
+func selectorSensitive(selector *metav1.LabelSelector, components bool) bool {
+	if selector == nil {
+		return false
+	}
+	for key, value := range selector.MatchLabels {
+		if contains(sensitiveKeys, key) || (components && contains(sensitiveComponents, value)) {
+			return true
+		}
+	}
+	for _, r := range selector.MatchExpressions {
+		if r.Operator != metav1.LabelSelectorOpIn && r.Operator != metav1.LabelSelectorOpExists {
+			continue
+		}
+		if contains(sensitiveKeys, r.Key) {
+			return true
+		}
+		for _, value := range r.Values {
+			if components && contains(sensitiveComponents, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewSchedulingBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewSchedulingBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package scheduling
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeSelectorTermSensitive(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator v1.NodeSelectorOperator
+		want     bool
+	}{
+		{"in", v1.NodeSelectorOpIn, true},
+		{"exists", v1.NodeSelectorOpExists, true},
+		{"does not exist", v1.NodeSelectorOpDoesNotExist, false},
+		{"not in", v1.NodeSelectorOpNotIn, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: "node-role.kubernetes.io/control-plane", Operator: tt.operator},
+			}}
+			if got := nodeSelectorTerm("nodeAffinity (required)", term).sensitive; got != tt.want {
+				t.Errorf("nodeSelectorTerm().sensitive = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorSensitive(t *testing.T) {
+	tests := []struct {
+		name       string
+		selector   *metav1.LabelSelector
+		components bool
+		want       bool
+	}{
+		{"nil", nil, true, false},
+		{"component label", &metav1.LabelSelector{MatchLabels: map[string]string{"component": "etcd"}}, true, true},
+		{"component label ignored", &metav1.LabelSelector{MatchLabels: map[string]string{"component": "etcd"}}, false, false},
+		{"component not in", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "component", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"kube-apiserver"}},
+		}}, true, false},
+		{"component in", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "component", Operator: metav1.LabelSelectorOpIn, Values: []string{"kube-apiserver"}},
+		}}, true, true},
+		{"unrelated", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectorSensitive(tt.selector, tt.components); got != tt.want {
+				t.Errorf("selectorSensitive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package seccompops
+
+import (
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "seccompops"
+	bucketDescription = "SeccompOps reports the seccomp operations supported by the kernel and if the process could install its own seccomp filter."
+)
+
+var bucketAliases = []string{"seccompfilter", "prctl"}
+
+type Bucket struct{}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewSeccompOpsBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewSeccompOpsBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
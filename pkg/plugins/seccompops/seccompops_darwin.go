@@ -0,0 +1,12 @@
+package seccompops
+
+import (
+	"context"
+	"errors"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	return bucket.Results{}, errors.New("seccomp is not supported on macOS")
+}
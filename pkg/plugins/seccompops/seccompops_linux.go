@@ -0,0 +1,98 @@
+package seccompops
+
+import (
+	"context"
+	"fmt"
+	"unsafe"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/capabilities"
+	"github.com/syndtr/gocapability/capability"
+	"golang.org/x/sys/unix"
+)
+
+var seccompModes = map[int]string{
+	unix.SECCOMP_MODE_DISABLED: "disabled",
+	unix.SECCOMP_MODE_STRICT:   "strict",
+	unix.SECCOMP_MODE_FILTER:   "filter",
+}
+
+var seccompActions = []struct {
+	name   string
+	action uint32
+}{
+	{"KILL_PROCESS", unix.SECCOMP_RET_KILL_PROCESS},
+	{"KILL_THREAD", unix.SECCOMP_RET_KILL_THREAD},
+	{"TRAP", unix.SECCOMP_RET_TRAP},
+	{"ERRNO", unix.SECCOMP_RET_ERRNO},
+	{"USER_NOTIF", unix.SECCOMP_RET_USER_NOTIF},
+	{"TRACE", unix.SECCOMP_RET_TRACE},
+	{"LOG", unix.SECCOMP_RET_LOG},
+	{"ALLOW", unix.SECCOMP_RET_ALLOW},
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	mode, err := unix.PrctlRetInt(unix.PR_GET_SECCOMP, 0, 0, 0, 0)
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to get the seccomp mode: %w", err)
+	}
+	noNewPrivs, err := unix.PrctlRetInt(unix.PR_GET_NO_NEW_PRIVS, 0, 0, 0, 0)
+	if err != nil {
+		return bucket.Results{}, fmt.Errorf("failed to get the no_new_privs flag: %w", err)
+	}
+
+	caps, err := capabilities.GetCapabilities(0)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	capSysAdmin := false
+	for _, c := range caps[capability.EFFECTIVE] {
+		if c == capability.CAP_SYS_ADMIN {
+			capSysAdmin = true
+		}
+	}
+
+	// the kernel refuses SECCOMP_SET_MODE_FILTER without one of them
+	filterInstallable := noNewPrivs == 1 || capSysAdmin
+
+	var actions []string
+	for _, a := range seccompActions {
+		action := a.action
+		if seccomp(unix.SECCOMP_GET_ACTION_AVAIL, unsafe.Pointer(&action)) == nil {
+			actions = append(actions, a.name)
+		}
+	}
+
+	// struct seccomp_notif_sizes, only used to check the operation support
+	var sizes [3]uint16
+	notifSizes := seccomp(unix.SECCOMP_GET_NOTIF_SIZES, unsafe.Pointer(&sizes)) == nil
+
+	modeName, found := seccompModes[mode]
+	if !found {
+		modeName = fmt.Sprintf("unknown (%d)", mode)
+	}
+
+	res.SetHeaders([]string{"mode", "noNewPrivs", "capSysAdmin", "filterInstallable", "availableActions", "notifSizes"})
+	res.AddContent([]interface{}{modeName, noNewPrivs == 1, capSysAdmin, filterInstallable, actions, notifSizes})
+
+	if noNewPrivs == 0 {
+		res.AddComment("no_new_privs is not set, setuid binaries and file capabilities can still grant privileges.")
+	}
+	if filterInstallable {
+		res.AddComment("The process could install its own seccomp filter, which is harmless and could only restrict it further.")
+	}
+
+	return *res, nil
+}
+
+// seccomp uses the seccomp syscall directly since prctl does not expose the
+// operations added after PR_SET_SECCOMP.
+func seccomp(operation uintptr, args unsafe.Pointer) error {
+	_, _, errno := unix.Syscall(unix.SYS_SECCOMP, operation, 0, uintptr(args))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -1,34 +1,126 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/egress"
 )
 
 const (
 	bucketName        = "services"
-	bucketDescription = "Services uses CoreDNS wildcards feature to discover every service available in the cluster."
+	bucketDescription = "Services discovers the services available in the cluster from the environment variables, the DNS and the CoreDNS wildcards feature."
 )
 
 var bucketAliases = []string{"service", "svc"}
 
-type Bucket struct{}
+const (
+	sourceEnv      = "env"
+	sourceDNS      = "dns"
+	sourceWildcard = "dns-wildcard"
+)
 
-func (n Bucket) Run() (bucket.Results, error) {
+const (
+	serviceHostSuffix = "_SERVICE_HOST"
+	servicePortSuffix = "_SERVICE_PORT"
+)
+
+// apiServerDomain is resolved to find the API server even when the
+// environment variables were not injected.
+const apiServerDomain = "kubernetes.default.svc"
+
+type Bucket struct {
+	probe bool
+}
+
+// endpoint is a service discovered from a source.
+type endpoint struct {
+	service string
+	host    string
+	port    string
+	source  string
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
-	_, hosts, err := net.LookupSRV("", "", "any.any.svc.cluster.local")
+
+	endpoints := envEndpoints(os.Environ())
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, apiServerDomain)
+	if err != nil {
+		res.AddComment(fmt.Sprintf("Failed to resolve %s: %s", apiServerDomain, err))
+	}
+	for _, addr := range addrs {
+		endpoints = append(endpoints, endpoint{"kubernetes", addr, "443", sourceDNS})
+	}
+
+	// CoreDNS removed the wildcards in v1.9.0, it only works on old clusters
+	_, srvs, err := net.DefaultResolver.LookupSRV(ctx, "", "", "any.any.svc.cluster.local")
 	if err != nil {
-		return *res, fmt.Errorf("error when requesting coreDNS: %w", err)
+		res.AddComment(fmt.Sprintf("Failed to use the CoreDNS wildcards: %s", err))
+	}
+	for _, srv := range srvs {
+		endpoints = append(endpoints, endpoint{strings.TrimSuffix(srv.Target, "."), srv.Target, fmt.Sprint(srv.Port), sourceWildcard})
 	}
-	res.SetHeaders([]string{"service", "port"})
-	for _, svc := range hosts {
-		res.AddContent([]interface{}{svc.Target, svc.Port})
+
+	if !n.probe {
+		res.SetHeaders([]string{"service", "host", "port", "source"})
+		for _, e := range endpoints {
+			res.AddContent([]interface{}{e.service, e.host, e.port, e.source})
+		}
+		return *res, nil
+	}
+
+	addresses := make([]string, len(endpoints))
+	for i, e := range endpoints {
+		addresses[i] = net.JoinHostPort(e.host, e.port)
 	}
+	probes := egress.TCPAll(ctx, addresses, egress.DefaultTimeout)
+	reachable := 0
+	res.SetHeaders([]string{"service", "host", "port", "source", "reachable"})
+	for i, e := range endpoints {
+		if probes[i].Reachable {
+			reachable++
+		}
+		res.AddContent([]interface{}{e.service, e.host, e.port, e.source, probes[i].Reachable})
+	}
+	res.AddComment(fmt.Sprintf("%d of the %d endpoints are reachable over TCP.", reachable, len(endpoints)))
 	return *res, nil
 }
 
+// envEndpoints extracts the services from the variables injected by the
+// kubelet, FOO_SERVICE_HOST and FOO_SERVICE_PORT for the foo service.
+func envEndpoints(environ []string) []endpoint {
+	vars := make(map[string]string)
+	for _, env := range environ {
+		if name, value, found := strings.Cut(env, "="); found {
+			vars[name] = value
+		}
+	}
+	var endpoints []endpoint
+	for name, host := range vars {
+		prefix, found := strings.CutSuffix(name, serviceHostSuffix)
+		if !found || prefix == "" {
+			continue
+		}
+		endpoints = append(endpoints, endpoint{
+			service: strings.ReplaceAll(strings.ToLower(prefix), "_", "-"),
+			host:    host,
+			port:    vars[prefix+servicePortSuffix],
+			source:  sourceEnv,
+		})
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].service < endpoints[j].service
+	})
+	return endpoints
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
@@ -42,6 +134,6 @@ func Register(b *bucket.Buckets) {
 	})
 }
 
-func NewServicesBucket(_ bucket.Config) (*Bucket, error) {
-	return &Bucket{}, nil
+func NewServicesBucket(config bucket.Config) (*Bucket, error) {
+	return &Bucket{probe: config.ServicesProbe}, nil
 }
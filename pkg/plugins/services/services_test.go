@@ -0,0 +1,25 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnvEndpoints(t *testing.T) {
+	environ := []string{
+		"KUBERNETES_SERVICE_HOST=10.96.0.1",
+		"KUBERNETES_SERVICE_PORT=443",
+		"KUBERNETES_SERVICE_PORT_HTTPS=443",
+		"MY_APP_SERVICE_HOST=10.96.12.34",
+		"MY_APP_SERVICE_PORT=8080",
+		"_SERVICE_HOST=10.0.0.1",
+		"HOME=/root",
+	}
+	want := []endpoint{
+		{"kubernetes", "10.96.0.1", "443", sourceEnv},
+		{"my-app", "10.96.12.34", "8080", sourceEnv},
+	}
+	if got := envEndpoints(environ); !reflect.DeepEqual(got, want) {
+		t.Errorf("envEndpoints() = %v, want %v", got, want)
+	}
+}
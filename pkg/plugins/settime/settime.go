@@ -0,0 +1,31 @@
+package settime
+
+import (
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	bucketName        = "settime"
+	bucketDescription = "SetTime checks if the container can set the host clock, shared by all the workloads of the node."
+)
+
+var bucketAliases = []string{"adjtime", "systime"}
+
+type Bucket struct{}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewSetTimeBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: false,
+	})
+}
+
+func NewSetTimeBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -0,0 +1,57 @@
+package settime
+
+import (
+	"context"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/capabilities"
+	"github.com/syndtr/gocapability/capability"
+	"golang.org/x/sys/unix"
+)
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	caps, err := capabilities.GetCapabilities(0)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+	hasCap := false
+	for _, c := range caps[capability.EFFECTIVE] {
+		if c == capability.CAP_SYS_TIME {
+			hasCap = true
+		}
+	}
+
+	probeErr := probeAdjtimex()
+	settable := probeErr == nil
+
+	res.SetHeaders([]string{"capSysTime", "adjtimexProbe", "settable"})
+	probe := "accepted"
+	if probeErr != nil {
+		probe = probeErr.Error()
+	}
+	res.AddContent([]interface{}{hasCap, probe, settable})
+
+	if settable {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment("The container can set the host clock, it would affect every workload of the node and could break TLS and tokens validation.")
+	} else if hasCap {
+		res.AddComment("CAP_SYS_TIME is present but the probe was rejected, the syscall might be blocked by seccomp.")
+	}
+
+	return *res, nil
+}
+
+// probeAdjtimex reads the current tick value and writes it back, this needs
+// CAP_SYS_TIME but leaves the clock unchanged.
+func probeAdjtimex() error {
+	var timex unix.Timex
+	// with no mode set, adjtimex is a read only operation
+	if _, err := unix.Adjtimex(&timex); err != nil {
+		return err
+	}
+	timex.Modes = unix.ADJ_TICK
+	_, err := unix.Adjtimex(&timex)
+	return err
+}
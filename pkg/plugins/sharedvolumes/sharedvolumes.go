@@ -0,0 +1,169 @@
+package sharedvolumes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/automaticontext"
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	bucketName        = "sharedvolumes"
+	bucketDescription = "SharedVolumes lists the volumes of the pod mounted into multiple containers and flags the writable ones."
+)
+
+var bucketAliases = []string{"sharedvolume", "sv"}
+
+// sidecarPrefixes are names of containers injected by service meshes and
+// agents, they usually run with different privileges than the application.
+var sidecarPrefixes = []string{"istio-", "linkerd-", "envoy", "consul-", "vault-agent", "datadog", "fluent"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+type volumeUsage struct {
+	containers []string
+	// at least one container mounts the volume without the readOnly flag
+	writable bool
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	pod, err := automaticontext.CurrentPod(ctx, n.config.Client, n.config.Namespace)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	// init containers are included since sidecars can be declared as
+	// restartable init containers
+	containers := append([]v1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+
+	usages := volumeUsages(containers)
+
+	res.SetHeaders([]string{"volume", "type", "containers", "writable"})
+	var writables, withSidecars []string
+	// iterate over the volumes of the spec for the output to be stable
+	for _, v := range pod.Spec.Volumes {
+		u, found := usages[v.Name]
+		if !found || len(u.containers) < 2 {
+			continue
+		}
+		writable := u.writable && isWritableSource(v)
+		res.AddContent([]interface{}{v.Name, volumeType(v), u.containers, writable})
+		if !writable {
+			continue
+		}
+		writables = append(writables, v.Name)
+		if hasSidecar(u.containers) {
+			withSidecars = append(withSidecars, v.Name)
+		}
+	}
+
+	if len(withSidecars) > 0 {
+		res.RaiseSeverity(bucket.SeverityMedium)
+		res.AddComment(fmt.Sprintf("Writable volumes are shared with sidecars, a compromised container can tamper with the files of the other: %v.", withSidecars))
+	} else if len(writables) > 0 {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment(fmt.Sprintf("Writable volumes are shared between containers: %v.", writables))
+	}
+
+	return *res, nil
+}
+
+// volumeUsages returns the containers mounting each volume, a container
+// mounting the same volume several times, with different subPaths for
+// example, is only listed once.
+func volumeUsages(containers []v1.Container) map[string]*volumeUsage {
+	usages := map[string]*volumeUsage{}
+	for _, c := range containers {
+		for _, vm := range c.VolumeMounts {
+			u, found := usages[vm.Name]
+			if !found {
+				u = &volumeUsage{}
+				usages[vm.Name] = u
+			}
+			if !contains(u.containers, c.Name) {
+				u.containers = append(u.containers, c.Name)
+			}
+			u.writable = u.writable || !vm.ReadOnly
+		}
+	}
+	return usages
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isWritableSource returns false for the volume types that the kubelet always
+// mounts read only.
+func isWritableSource(v v1.Volume) bool {
+	return v.ConfigMap == nil && v.Secret == nil && v.Projected == nil && v.DownwardAPI == nil
+}
+
+func volumeType(v v1.Volume) string {
+	switch {
+	case v.EmptyDir != nil:
+		return "emptyDir"
+	case v.HostPath != nil:
+		return "hostPath"
+	case v.ConfigMap != nil:
+		return "configMap"
+	case v.Secret != nil:
+		return "secret"
+	case v.Projected != nil:
+		return "projected"
+	case v.DownwardAPI != nil:
+		return "downwardAPI"
+	case v.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case v.Ephemeral != nil:
+		return "ephemeral"
+	default:
+		return "other"
+	}
+}
+
+func hasSidecar(containers []string) bool {
+	for _, c := range containers {
+		for _, prefix := range sidecarPrefixes {
+			if strings.HasPrefix(c, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewSharedVolumesBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: true,
+	})
+}
+
+func NewSharedVolumesBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}
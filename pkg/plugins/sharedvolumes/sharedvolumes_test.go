@@ -0,0 +1,33 @@
+package sharedvolumes
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestVolumeUsages(t *testing.T) {
+	containers := []v1.Container{
+		{Name: "app", VolumeMounts: []v1.VolumeMount{
+			// the same emptyDir mounted twice is not shared
+			{Name: "cache", MountPath: "/cache/a", SubPath: "a"},
+			{Name: "cache", MountPath: "/cache/b", SubPath: "b"},
+			{Name: "logs", MountPath: "/var/log/app"},
+		}},
+		{Name: "fluent-bit", VolumeMounts: []v1.VolumeMount{
+			{Name: "logs", MountPath: "/logs", ReadOnly: true},
+		}},
+	}
+
+	usages := volumeUsages(containers)
+	if got, want := usages["cache"].containers, []string{"app"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("volumeUsages() cache containers = %v, want %v", got, want)
+	}
+	if got, want := usages["logs"].containers, []string{"app", "fluent-bit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("volumeUsages() logs containers = %v, want %v", got, want)
+	}
+	if !usages["logs"].writable {
+		t.Error("volumeUsages() logs writable = false, want true")
+	}
+}
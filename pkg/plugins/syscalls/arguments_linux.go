@@ -0,0 +1,135 @@
+//go:build linux && (amd64 || arm64)
+
+package syscalls
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// syscallInvoker performs a syscall with three arguments and returns the
+// first return value, it is replaced by a fake in tests.
+type syscallInvoker func(id int, args [3]uintptr) (uintptr, error)
+
+func rawInvoker(id int, args [3]uintptr) (uintptr, error) {
+	r1, _, errno := syscall.Syscall(uintptr(id), args[0], args[1], args[2])
+	if errno != 0 {
+		return r1, errno
+	}
+	return r1, nil
+}
+
+// personality values from linux/personality.h, missing in x/sys/unix
+const (
+	perLinux         = 0x0000000
+	addrNoRandomize  = 0x0040000
+	personalityQuery = 0xffffffff
+)
+
+type argCombination struct {
+	label string
+	args  [3]uintptr
+}
+
+// argProbe is a syscall that seccomp profiles commonly filter by argument,
+// cleanup undoes the effect of a successful call.
+type argProbe struct {
+	id           int
+	combinations []argCombination
+	cleanup      func(invoke syscallInvoker, r1 uintptr)
+}
+
+// argProbes are the curated syscalls filtered by argument in the Docker
+// default profile that can be called safely, clone is filtered as well but
+// would fork the process.
+// https://github.com/moby/moby/blob/master/profiles/seccomp/default.json
+var argProbes = []argProbe{
+	{
+		id: unix.SYS_PERSONALITY,
+		combinations: []argCombination{
+			{"QUERY", [3]uintptr{personalityQuery}},
+			{"PER_LINUX", [3]uintptr{perLinux}},
+			{"ADDR_NO_RANDOMIZE", [3]uintptr{addrNoRandomize}},
+		},
+		// personality returns the previous persona, restore it
+		cleanup: func(invoke syscallInvoker, r1 uintptr) {
+			_, _ = invoke(unix.SYS_PERSONALITY, [3]uintptr{r1})
+		},
+	},
+	{
+		id: unix.SYS_SOCKET,
+		combinations: []argCombination{
+			{"AF_INET", [3]uintptr{unix.AF_INET, unix.SOCK_STREAM}},
+			{"AF_NETLINK", [3]uintptr{unix.AF_NETLINK, unix.SOCK_RAW, unix.NETLINK_ROUTE}},
+			{"AF_VSOCK", [3]uintptr{unix.AF_VSOCK, unix.SOCK_STREAM}},
+		},
+		cleanup: func(invoke syscallInvoker, r1 uintptr) {
+			_, _ = invoke(unix.SYS_CLOSE, [3]uintptr{r1})
+		},
+	},
+}
+
+// argumentScan calls the probed syscalls with every argument combination
+// and marks them conditional if some combinations, including the zero
+// arguments of the regular scan, are blocked and others allowed. It returns
+// the details of the conditional syscalls.
+func argumentScan(invoke syscallInvoker, probes []argProbe, results []SyscallScanResult) []string {
+	index := make(map[int]int, len(results))
+	for i, r := range results {
+		index[r.ID] = i
+	}
+
+	var details []string
+	for _, p := range probes {
+		i, ok := index[p.id]
+		if !ok {
+			continue
+		}
+		var allowed, blocked []string
+		if results[i].Allowed {
+			allowed = append(allowed, "zero arguments")
+		} else {
+			blocked = append(blocked, "zero arguments")
+		}
+		for _, c := range p.combinations {
+			if p.call(invoke, c.args) {
+				blocked = append(blocked, c.label)
+			} else {
+				allowed = append(allowed, c.label)
+			}
+		}
+		if len(allowed) > 0 && len(blocked) > 0 {
+			results[i].Allowed = true
+			results[i].Conditional = true
+			details = append(details, fmt.Sprintf("%s is allowed with %v and blocked with %v.", syscallLabel(p.id), allowed, blocked))
+		}
+	}
+	return details
+}
+
+// call invokes the probe and its cleanup on the same locked OS thread since
+// some syscalls like personality only affect the calling thread. It returns
+// true if the call was blocked.
+func (p argProbe) call(invoke syscallInvoker, args [3]uintptr) bool {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	r1, err := invoke(p.id, args)
+	if isBlockedError(err) {
+		return true
+	}
+	if err == nil && p.cleanup != nil {
+		p.cleanup(invoke, r1)
+	}
+	return false
+}
+
+// isBlockedError returns true for the errors returned by seccomp filters or
+// LSMs, other errors mean that the call reached the kernel implementation.
+func isBlockedError(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EOPNOTSUPP)
+}
@@ -0,0 +1,70 @@
+//go:build linux && (amd64 || arm64)
+
+package syscalls
+
+import (
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestArgumentScan(t *testing.T) {
+	// the fake filter blocks AF_VSOCK sockets like the Docker default profile
+	// and allows everything else
+	var closed []uintptr
+	invoke := func(id int, args [3]uintptr) (uintptr, error) {
+		switch {
+		case id == unix.SYS_SOCKET && args[0] == unix.AF_VSOCK:
+			return 0, syscall.EPERM
+		case id == unix.SYS_SOCKET:
+			return 42, nil
+		case id == unix.SYS_CLOSE:
+			closed = append(closed, args[0])
+		}
+		return 0, nil
+	}
+
+	results := []SyscallScanResult{
+		{ID: unix.SYS_SOCKET, Allowed: true},
+		{ID: unix.SYS_PERSONALITY, Allowed: true},
+		{ID: unix.SYS_GETPID, Allowed: true},
+	}
+	details := argumentScan(invoke, argProbes, results)
+
+	want := []SyscallScanResult{
+		{ID: unix.SYS_SOCKET, Allowed: true, Conditional: true},
+		{ID: unix.SYS_PERSONALITY, Allowed: true},
+		{ID: unix.SYS_GETPID, Allowed: true},
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("results[%d] = %+v, want %+v", i, results[i], want[i])
+		}
+	}
+	if len(details) != 1 {
+		t.Errorf("details = %v, want a single conditional syscall", details)
+	}
+	// the two allowed socket combinations must be closed
+	if len(closed) != 2 || closed[0] != 42 || closed[1] != 42 {
+		t.Errorf("closed = %v, want the two sockets created to be closed", closed)
+	}
+}
+
+func TestArgumentScanBlockedWithZeroArguments(t *testing.T) {
+	// blocked with zero arguments but allowed with some is conditional too
+	invoke := func(id int, args [3]uintptr) (uintptr, error) {
+		if id == unix.SYS_PERSONALITY && args[0] != personalityQuery {
+			return 0, syscall.EPERM
+		}
+		return 0, nil
+	}
+
+	results := []SyscallScanResult{{ID: unix.SYS_PERSONALITY, Allowed: false}}
+	argumentScan(invoke, argProbes, results)
+
+	want := SyscallScanResult{ID: unix.SYS_PERSONALITY, Allowed: true, Conditional: true}
+	if results[0] != want {
+		t.Errorf("results[0] = %+v, want %+v", results[0], want)
+	}
+}
@@ -0,0 +1,185 @@
+//go:build linux && (amd64 || arm64)
+
+package syscalls
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// scanFormatVersion must be increased on incompatible changes of the saved
+// scan format.
+const scanFormatVersion = 1
+
+// savedScan is the serialization format of a scan, syscall numbers are only
+// comparable between scans of the same architecture.
+type savedScan struct {
+	Version      int            `json:"version"`
+	Architecture string         `json:"architecture"`
+	Syscalls     []savedSyscall `json:"syscalls"`
+}
+
+type savedSyscall struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Allowed bool   `json:"allowed"`
+	// conditional was added without changing the version, it is only set
+	// by the deep mode
+	Conditional bool `json:"conditional,omitempty"`
+}
+
+// syscallChange is a syscall whose state differs between two scans.
+type syscallChange struct {
+	ID       int
+	Previous string
+	Current  string
+}
+
+// SaveScan writes the scan results to path, to be compared later with
+// LoadScan.
+func SaveScan(path string, results []SyscallScanResult) error {
+	scan := savedScan{
+		Version:      scanFormatVersion,
+		Architecture: runtime.GOARCH,
+		Syscalls:     make([]savedSyscall, 0, len(results)),
+	}
+	for _, r := range results {
+		scan.Syscalls = append(scan.Syscalls, savedSyscall{ID: r.ID, Name: syscallIDToName(r.ID), Allowed: r.Allowed, Conditional: r.Conditional})
+	}
+	raw, err := json.MarshalIndent(scan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
+// LoadScan reads scan results saved with SaveScan or the JSON output of the
+// bucket, the names are ignored and only the numbers are used.
+func LoadScan(path string) ([]SyscallScanResult, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var scan savedScan
+	if err := json.Unmarshal(raw, &scan); err != nil {
+		return nil, fmt.Errorf("failed to parse the saved scan %s: %w", path, err)
+	}
+	if scan.Version == 0 {
+		// not a saved scan, it might be the JSON output of the bucket
+		return loadResultsOutput(path, raw)
+	}
+	if scan.Version != scanFormatVersion {
+		return nil, fmt.Errorf("saved scan %s has version %d, only version %d is supported", path, scan.Version, scanFormatVersion)
+	}
+	if scan.Architecture != runtime.GOARCH {
+		return nil, fmt.Errorf("saved scan %s was made on %s, syscall numbers differ on %s", path, scan.Architecture, runtime.GOARCH)
+	}
+	results := make([]SyscallScanResult, 0, len(scan.Syscalls))
+	for _, s := range scan.Syscalls {
+		results = append(results, SyscallScanResult{ID: s.ID, Allowed: s.Allowed, Conditional: s.Conditional})
+	}
+	return results, nil
+}
+
+// loadResultsOutput reads the output of "kdigger dig syscalls -o json", it
+// does not contain the architecture that can't be checked.
+func loadResultsOutput(path string, raw []byte) ([]SyscallScanResult, error) {
+	var output struct {
+		Bucket string `json:"bucket"`
+		Result struct {
+			Blocked     []syscallEntry `json:"blocked"`
+			Conditional []syscallEntry `json:"conditional"`
+			Allowed     []syscallEntry `json:"allowed"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse the saved scan %s: %w", path, err)
+	}
+	if output.Bucket != bucketName {
+		return nil, fmt.Errorf("%s is neither a saved scan nor the JSON output of the %s bucket", path, bucketName)
+	}
+	var results []SyscallScanResult
+	for _, e := range output.Result.Blocked {
+		results = append(results, SyscallScanResult{ID: e.Number})
+	}
+	for _, e := range output.Result.Conditional {
+		results = append(results, SyscallScanResult{ID: e.Number, Allowed: true, Conditional: true})
+	}
+	for _, e := range output.Result.Allowed {
+		results = append(results, SyscallScanResult{ID: e.Number, Allowed: true})
+	}
+	return results, nil
+}
+
+// scanDiff separates the syscalls present in both scans by transition,
+// otherChanges are the transitions from or to the conditional state that
+// are neither blocked nor allowed.
+type scanDiff struct {
+	newlyBlocked []syscallEntry
+	newlyAllowed []syscallEntry
+	unchanged    []syscallEntry
+	otherChanges []syscallChange
+}
+
+func diffScans(previous []SyscallScanResult, current []SyscallScanResult) scanDiff {
+	var diff scanDiff
+	changed := make(map[int]bool)
+	for _, c := range compareScans(previous, current) {
+		changed[c.ID] = true
+		switch {
+		case c.Current == stateBlocked:
+			diff.newlyBlocked = append(diff.newlyBlocked, newSyscallEntry(c.ID))
+		case c.Previous == stateBlocked:
+			diff.newlyAllowed = append(diff.newlyAllowed, newSyscallEntry(c.ID))
+		default:
+			diff.otherChanges = append(diff.otherChanges, c)
+		}
+	}
+	scanned := make(map[int]bool, len(previous))
+	for _, r := range previous {
+		scanned[r.ID] = true
+	}
+	for _, r := range current {
+		if scanned[r.ID] && !changed[r.ID] {
+			diff.unchanged = append(diff.unchanged, newSyscallEntry(r.ID))
+		}
+	}
+	return diff
+}
+
+// compareScans returns the syscalls present in both scans whose state
+// changed, in the order of the current scan.
+func compareScans(previous []SyscallScanResult, current []SyscallScanResult) []syscallChange {
+	previousStates := make(map[int]string, len(previous))
+	for _, r := range previous {
+		previousStates[r.ID] = syscallState(r)
+	}
+	var changes []syscallChange
+	for _, r := range current {
+		state, ok := previousStates[r.ID]
+		if !ok || state == syscallState(r) {
+			continue
+		}
+		changes = append(changes, syscallChange{ID: r.ID, Previous: state, Current: syscallState(r)})
+	}
+	return changes
+}
+
+const (
+	stateAllowed     = "allowed"
+	stateBlocked     = "blocked"
+	stateConditional = "conditional"
+)
+
+func syscallState(r SyscallScanResult) string {
+	switch {
+	case r.Conditional:
+		return stateConditional
+	case r.Allowed:
+		return stateAllowed
+	default:
+		return stateBlocked
+	}
+}
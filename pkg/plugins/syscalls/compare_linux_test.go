@@ -0,0 +1,148 @@
+//go:build linux && (amd64 || arm64)
+
+package syscalls
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func entryIDs(entries []syscallEntry) []int {
+	var ids []int
+	for _, e := range entries {
+		ids = append(ids, e.Number)
+	}
+	return ids
+}
+
+func TestDiffScans(t *testing.T) {
+	previous := []SyscallScanResult{
+		{ID: unix.SYS_READ, Allowed: true},
+		{ID: unix.SYS_WRITE, Allowed: true},
+		{ID: unix.SYS_MOUNT},
+		{ID: unix.SYS_SOCKET, Allowed: true},
+		{ID: unix.SYS_PERSONALITY, Allowed: true, Conditional: true},
+		// only in the previous scan, it was removed from the current one
+		{ID: unix.SYS_UNSHARE, Allowed: true},
+	}
+	current := []SyscallScanResult{
+		{ID: unix.SYS_READ, Allowed: true},
+		{ID: unix.SYS_WRITE},
+		{ID: unix.SYS_MOUNT, Allowed: true},
+		{ID: unix.SYS_SOCKET, Allowed: true, Conditional: true},
+		{ID: unix.SYS_PERSONALITY, Allowed: true, Conditional: true},
+		// only in the current scan, it was added since the previous one
+		{ID: unix.SYS_SETNS, Allowed: true},
+	}
+
+	diff := diffScans(previous, current)
+	if got, want := entryIDs(diff.newlyBlocked), []int{unix.SYS_WRITE}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffScans() newlyBlocked = %v, want %v", got, want)
+	}
+	if got, want := entryIDs(diff.newlyAllowed), []int{unix.SYS_MOUNT}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffScans() newlyAllowed = %v, want %v", got, want)
+	}
+	if got, want := entryIDs(diff.unchanged), []int{unix.SYS_READ, unix.SYS_PERSONALITY}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diffScans() unchanged = %v, want %v", got, want)
+	}
+	wantOther := []syscallChange{{ID: unix.SYS_SOCKET, Previous: stateAllowed, Current: stateConditional}}
+	if !reflect.DeepEqual(diff.otherChanges, wantOther) {
+		t.Errorf("diffScans() otherChanges = %+v, want %+v", diff.otherChanges, wantOther)
+	}
+}
+
+func TestLoadScan(t *testing.T) {
+	saved := []SyscallScanResult{
+		{ID: unix.SYS_READ, Allowed: true},
+		{ID: unix.SYS_MOUNT},
+		{ID: unix.SYS_SOCKET, Allowed: true, Conditional: true},
+	}
+	output := fmt.Sprintf(`{"bucket": %q, "result": {
+		"blocked": [{"name": "mount", "number": %d}],
+		"conditional": [{"name": "socket", "number": %d}],
+		"allowed": [{"name": "read", "number": %d}]
+	}}`, bucketName, unix.SYS_MOUNT, unix.SYS_SOCKET, unix.SYS_READ)
+
+	tests := []struct {
+		name    string
+		content string
+		want    []SyscallScanResult
+		wantErr bool
+	}{
+		{
+			name:    "bucket output",
+			content: output,
+			want: []SyscallScanResult{
+				{ID: unix.SYS_MOUNT},
+				{ID: unix.SYS_SOCKET, Allowed: true, Conditional: true},
+				{ID: unix.SYS_READ, Allowed: true},
+			},
+		},
+		{
+			name:    "malformed json",
+			content: `{"version": 1, "syscalls": [`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported version",
+			content: fmt.Sprintf(`{"version": %d, "architecture": %q, "syscalls": []}`, scanFormatVersion+1, runtime.GOARCH),
+			wantErr: true,
+		},
+		{
+			name:    "other architecture",
+			content: fmt.Sprintf(`{"version": %d, "architecture": "mips", "syscalls": []}`, scanFormatVersion),
+			wantErr: true,
+		},
+		{
+			name:    "other bucket output",
+			content: `{"bucket": "capabilities", "result": {}}`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong field types",
+			content: fmt.Sprintf(`{"bucket": %q, "result": {"allowed": "read"}}`, bucketName),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scan.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadScan(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadScan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadScan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("saved scan", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "scan.json")
+		if err := SaveScan(path, saved); err != nil {
+			t.Fatalf("SaveScan() error = %v", err)
+		}
+		got, err := LoadScan(path)
+		if err != nil {
+			t.Fatalf("LoadScan() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, saved) {
+			t.Errorf("LoadScan() = %+v, want %+v", got, saved)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := LoadScan(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+			t.Error("LoadScan() error = nil, want an error for a missing file")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+//go:build ignore
+
+// mktables generates the syscall names tables of the supported architectures
+// from the SYS_* constants of golang.org/x/sys/unix, in the version required
+// by the module. Run it with go generate after updating golang.org/x/sys.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// archs are the architectures supported by the scan.
+var archs = []string{"amd64", "arm64"}
+
+var constRegexp = regexp.MustCompile(`(?m)^\s+(SYS_\w+)\s+=\s+(\d+)$`)
+
+type syscall struct {
+	constant string
+	number   int
+}
+
+func main() {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "golang.org/x/sys").Output()
+	if err != nil {
+		log.Fatalf("failed to locate golang.org/x/sys: %v", err)
+	}
+	dir := filepath.Join(strings.TrimSpace(string(out)), "unix")
+
+	for _, arch := range archs {
+		src, err := os.ReadFile(filepath.Join(dir, "zsysnum_linux_"+arch+".go"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		var syscalls []syscall
+		for _, m := range constRegexp.FindAllStringSubmatch(string(src), -1) {
+			number, err := strconv.Atoi(m[2])
+			if err != nil {
+				log.Fatal(err)
+			}
+			syscalls = append(syscalls, syscall{constant: m[1], number: number})
+		}
+		sort.SliceStable(syscalls, func(i, j int) bool {
+			return syscalls[i].number < syscalls[j].number
+		})
+
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "// Code generated by mktables.go; DO NOT EDIT.\n\n")
+		fmt.Fprintf(&b, "//go:build linux && %s\n\n", arch)
+		fmt.Fprintf(&b, "package syscalls\n\n")
+		fmt.Fprintf(&b, "import \"golang.org/x/sys/unix\"\n\n")
+		fmt.Fprintf(&b, "// syscallNames maps the syscall numbers to their kernel names.\n")
+		fmt.Fprintf(&b, "var syscallNames = map[int]string{\n")
+		for _, s := range syscalls {
+			fmt.Fprintf(&b, "\tunix.%s: %q,\n", s.constant, strings.ToLower(strings.TrimPrefix(s.constant, "SYS_")))
+		}
+		fmt.Fprintf(&b, "}\n")
+
+		formatted, err := format.Source(b.Bytes())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile("zsyscalls_linux_"+arch+".go", formatted, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+//go:build linux && (amd64 || arm64)
+
+package syscalls
+
+import (
+	"encoding/json"
+)
+
+const (
+	seccompActionAllow = "SCMP_ACT_ALLOW"
+	seccompActionErrno = "SCMP_ACT_ERRNO"
+)
+
+// SeccompProfile is the seccomp profile format of the OCI runtimes, used by
+// the Localhost seccompProfile type of Kubernetes.
+type SeccompProfile struct {
+	DefaultAction string           `json:"defaultAction"`
+	Architectures []string         `json:"architectures"`
+	Syscalls      []SeccompSyscall `json:"syscalls"`
+}
+
+// SeccompSyscall is a rule of the profile, the comment is supported by the
+// Docker and containerd profiles parsers and ignored by the others.
+type SeccompSyscall struct {
+	Names   []string `json:"names"`
+	Action  string   `json:"action"`
+	Comment string   `json:"comment,omitempty"`
+}
+
+// GenerateSeccompProfile returns a seccomp profile denying everything but the
+// syscalls detected as allowed by the scan. The skipped syscalls could not be
+// probed and are allowed in a separate rule, exit and exit_group among them
+// are needed by any process.
+func (n Bucket) GenerateSeccompProfile(results []SyscallScanResult) ([]byte, error) {
+	var allowed []string
+	for _, r := range results {
+		if !r.Allowed {
+			continue
+		}
+		// seccomp can't resolve numbers without a name in libseccomp
+		if name, ok := knownSyscallName(r.ID); ok {
+			allowed = append(allowed, name)
+		}
+	}
+	skipped := make([]string, 0, len(skippedSyscalls))
+	for _, id := range skippedSyscalls {
+		if name, ok := knownSyscallName(id); ok {
+			skipped = append(skipped, name)
+		}
+	}
+
+	profile := SeccompProfile{
+		DefaultAction: seccompActionErrno,
+		Architectures: []string{seccompArch},
+		Syscalls: []SeccompSyscall{
+			{
+				Names:   allowed,
+				Action:  seccompActionAllow,
+				Comment: "syscalls detected as allowed by the kdigger scan",
+			},
+			{
+				Names:   skipped,
+				Action:  seccompActionAllow,
+				Comment: "syscalls not probed by the kdigger scan because they hang or exit the process, allowed to not miss them, review them before use",
+			},
+		},
+	}
+	return json.MarshalIndent(profile, "", "  ")
+}
+
+func knownSyscallName(id int) (string, bool) {
+	name := syscallIDToName(id)
+	return name, name != ""
+}
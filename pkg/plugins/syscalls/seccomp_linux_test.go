@@ -0,0 +1,76 @@
+//go:build linux && (amd64 || arm64)
+
+package syscalls
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestGenerateSeccompProfile(t *testing.T) {
+	results := []SyscallScanResult{
+		{ID: unix.SYS_READ, Allowed: true},
+		{ID: unix.SYS_WRITE, Allowed: true},
+		{ID: unix.SYS_MOUNT, Allowed: false},
+		{ID: unix.SYS_GETPID, Allowed: true},
+		// unknown syscalls can't be written in the profile
+		{ID: 9999, Allowed: true},
+	}
+
+	raw, err := Bucket{}.GenerateSeccompProfile(results)
+	if err != nil {
+		t.Fatalf("GenerateSeccompProfile() error = %v", err)
+	}
+
+	var profile map[string]interface{}
+	if err := json.Unmarshal(raw, &profile); err != nil {
+		t.Fatalf("GenerateSeccompProfile() returned invalid JSON: %v", err)
+	}
+
+	if got := profile["defaultAction"]; got != "SCMP_ACT_ERRNO" {
+		t.Errorf("defaultAction = %v, want SCMP_ACT_ERRNO", got)
+	}
+	if got := profile["architectures"]; !reflect.DeepEqual(got, []interface{}{seccompArch}) {
+		t.Errorf("architectures = %v, want [%s]", got, seccompArch)
+	}
+
+	syscalls, ok := profile["syscalls"].([]interface{})
+	if !ok || len(syscalls) != 2 {
+		t.Fatalf("syscalls = %v, want two rules", profile["syscalls"])
+	}
+	allowRule, ok := syscalls[0].(map[string]interface{})
+	if !ok {
+		t.Fatalf("syscalls[0] = %v, want an object", syscalls[0])
+	}
+	if got := allowRule["action"]; got != "SCMP_ACT_ALLOW" {
+		t.Errorf("syscalls[0].action = %v, want SCMP_ACT_ALLOW", got)
+	}
+	wantNames := []interface{}{"read", "write", "getpid"}
+	if got := allowRule["names"]; !reflect.DeepEqual(got, wantNames) {
+		t.Errorf("syscalls[0].names = %v, want %v", got, wantNames)
+	}
+
+	skippedRule, ok := syscalls[1].(map[string]interface{})
+	if !ok {
+		t.Fatalf("syscalls[1] = %v, want an object", syscalls[1])
+	}
+	if comment, _ := skippedRule["comment"].(string); comment == "" {
+		t.Error("syscalls[1].comment is empty, want an explanation of the skipped syscalls")
+	}
+	names, _ := skippedRule["names"].([]interface{})
+	if len(names) != len(skippedSyscalls) {
+		t.Errorf("syscalls[1].names = %v, want the %d skipped syscalls", names, len(skippedSyscalls))
+	}
+	found := false
+	for _, name := range names {
+		if name == "exit_group" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("syscalls[1].names = %v, want exit_group to be allowed", names)
+	}
+}
@@ -1,6 +1,9 @@
 package syscalls
 
 import (
+	"log/slog"
+	"time"
+
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
@@ -9,9 +12,27 @@ const (
 	bucketDescription = "Syscalls scans most of the syscalls to detect which are blocked and allowed."
 )
 
+// DefaultScanTimeout is the time after which a syscall that did not return
+// is considered allowed.
+const DefaultScanTimeout = 100 * time.Millisecond
+
 var bucketAliases = []string{"syscall", "sys"}
 
-type Bucket struct{}
+type Bucket struct {
+	logger   *slog.Logger
+	adaptive bool
+	// timeout is ignored in adaptive mode, the calibration chooses it
+	timeout time.Duration
+	// deep probes curated syscalls with several arguments combinations
+	deep bool
+	// seccompProfilePath is where the generated seccomp profile is written,
+	// empty if not requested
+	seccompProfilePath string
+	// savePath is where the scan is saved and comparePath the saved scan to
+	// compare with, empty if not requested
+	savePath    string
+	comparePath string
+}
 
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
@@ -26,6 +47,18 @@ func Register(b *bucket.Buckets) {
 	})
 }
 
-func NewSyscallsBucket(_ bucket.Config) (*Bucket, error) {
-	return &Bucket{}, nil
+func NewSyscallsBucket(config bucket.Config) (*Bucket, error) {
+	timeout := config.SyscallScanTimeout
+	if timeout <= 0 {
+		timeout = DefaultScanTimeout
+	}
+	return &Bucket{
+		logger:             config.Log(),
+		adaptive:           config.SyscallsAdaptive,
+		timeout:            timeout,
+		deep:               config.SyscallsDeep,
+		seccompProfilePath: config.SyscallsSeccompProfile,
+		savePath:           config.SyscallsSave,
+		comparePath:        config.SyscallsCompare,
+	}, nil
 }
@@ -1,11 +1,12 @@
 package syscalls
 
 import (
+	"context"
 	"errors"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
-func (n Bucket) Run() (bucket.Results, error) {
-	return bucket.Results{}, errors.New("syscall scan is not supported on macOS x86")
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	return bucket.Results{}, errors.New("syscall scan is not supported on macOS")
 }
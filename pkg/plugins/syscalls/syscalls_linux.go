@@ -0,0 +1,390 @@
+//go:build linux && (amd64 || arm64)
+
+//go:generate go run mktables.go
+
+package syscalls
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+const (
+	// calibrationSize is the number of syscalls probed to calibrate the
+	// adaptive mode, with the generous calibrationTimeout
+	calibrationSize    = 32
+	calibrationTimeout = time.Second
+	// the timeout is a multiple of the slowest rejection observed
+	timeoutFactor = 10
+	minTimeout    = 20 * time.Millisecond
+	maxTimeout    = time.Second
+	// a rejection slower than this means that the environment is throttled
+	slowRejection = 5 * time.Millisecond
+)
+
+// unknownSyscall is displayed for syscalls missing from the names table.
+const unknownSyscall = "ERR_UNKNOWN_SYSCALL"
+
+type SyscallScanResult struct {
+	ID      int
+	Allowed bool
+	// Conditional is set in deep mode for syscalls allowed with some
+	// arguments only, Allowed is true as well
+	Conditional bool
+}
+
+// scanParams are the parameters of the scan, workers is the number of
+// syscalls probed concurrently, zero meaning all of them at once.
+type scanParams struct {
+	workers int
+	timeout time.Duration
+	// logger receives a debug message for every syscall
+	logger *slog.Logger
+}
+
+type SeccompMode uint8
+
+const (
+	SeccompModeDisabled SeccompMode = iota
+	SeccompModeStrict
+	SeccompModeFilter
+)
+
+func (s SeccompMode) String() string {
+	switch s {
+	case SeccompModeDisabled:
+		return "SECCOMP_MODE_DISABLED"
+	case SeccompModeStrict:
+		return "SECCOMP_MODE_STRICT"
+	case SeccompModeFilter:
+		return "SECCOMP_MODE_FILTER"
+	default:
+		return "SECCOMP_MODE_UNKNOWN"
+	}
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	// scan the syscalls
+	ids := scannedSyscalls()
+	var results []SyscallScanResult
+	if n.adaptive {
+		var params scanParams
+		var calibrationResults []SyscallScanResult
+		calibrationResults, params = calibrate(ctx, ids[:min(calibrationSize, len(ids))])
+		params.logger = n.logger
+		n.logger.Info("calibrated the syscalls scan", "workers", params.workers, "timeout", params.timeout)
+		results = append(calibrationResults, syscallScan(ctx, ids[len(calibrationResults):], params)...)
+		workers := "unbounded"
+		if params.workers > 0 {
+			workers = fmt.Sprint(params.workers)
+		}
+		res.AddComment(fmt.Sprintf("Adaptive mode calibrated the scan with %s workers and a %s timeout.", workers, params.timeout))
+	} else {
+		n.logger.Info("scanning the syscalls", "count", len(ids), "timeout", n.timeout)
+		results = syscallScan(ctx, ids, scanParams{timeout: n.timeout, logger: n.logger})
+	}
+	// the results of an interrupted scan are incomplete
+	if err := ctx.Err(); err != nil {
+		return bucket.Results{}, fmt.Errorf("syscall scan interrupted: %w", err)
+	}
+
+	if n.deep {
+		for _, d := range argumentScan(rawInvoker, argProbes, results) {
+			res.AddComment(d)
+		}
+	}
+
+	if n.comparePath != "" {
+		// separate the syscalls by transition since the saved scan
+		previous, err := LoadScan(n.comparePath)
+		if err != nil {
+			return bucket.Results{}, err
+		}
+		diff := diffScans(previous, results)
+		res.SetHeaders([]string{"newlyBlocked", "newlyAllowed", "unchanged"})
+		res.AddContent([]interface{}{diff.newlyBlocked, diff.newlyAllowed, diff.unchanged})
+		res.AddComment(fmt.Sprintf("Compared to %s, %d syscalls became blocked, %d became allowed and %d are unchanged.", n.comparePath, len(diff.newlyBlocked), len(diff.newlyAllowed), len(diff.unchanged)))
+		for _, c := range diff.otherChanges {
+			res.AddComment(fmt.Sprintf("%s changed from %s to %s.", syscallLabel(c.ID), c.Previous, c.Current))
+		}
+	} else {
+		// format the results into two arrays, three in deep mode
+		var allowed []syscallEntry
+		var blocked []syscallEntry
+		var conditional []syscallEntry
+		for _, r := range results {
+			switch {
+			case r.Conditional:
+				conditional = append(conditional, newSyscallEntry(r.ID))
+			case r.Allowed:
+				allowed = append(allowed, newSyscallEntry(r.ID))
+			default:
+				blocked = append(blocked, newSyscallEntry(r.ID))
+			}
+		}
+		if n.deep {
+			res.SetHeaders([]string{"blocked", "conditional", "allowed"})
+			res.AddContent([]interface{}{blocked, conditional, allowed})
+		} else {
+			res.SetHeaders([]string{"blocked", "allowed"})
+			res.AddContent([]interface{}{blocked, allowed})
+		}
+	}
+
+	if n.savePath != "" {
+		if err := SaveScan(n.savePath, results); err != nil {
+			return bucket.Results{}, fmt.Errorf("failed to save the scan: %w", err)
+		}
+		res.AddComment(fmt.Sprintf("Scan saved to %s.", n.savePath))
+	}
+
+	if n.seccompProfilePath != "" {
+		profile, err := n.GenerateSeccompProfile(results)
+		if err != nil {
+			return bucket.Results{}, err
+		}
+		if err := os.WriteFile(n.seccompProfilePath, profile, 0o644); err != nil {
+			return bucket.Results{}, fmt.Errorf("failed to write the seccomp profile: %w", err)
+		}
+		res.AddComment(fmt.Sprintf("Seccomp profile allowing the allowed and skipped syscalls written to %s.", n.seccompProfilePath))
+	}
+
+	// output the skipped syscalls
+	var skippedNames [len(skippedSyscalls)]string
+	for i := range skippedSyscalls {
+		skippedNames[i] = syscallLabel(skippedSyscalls[i])
+	}
+	res.AddComment(fmt.Sprint(skippedNames) + " were not scanned because they cause hang or will exit the program.")
+
+	// output seccomp status
+	seccompFlag, err := readSeccompFlag()
+	if err != nil {
+		// this is an additional feature, do not "error" on this
+		res.AddComment(fmt.Sprintf("error reading the Seccomp flag: %s", err.Error()))
+	} else {
+		res.AddComment(fmt.Sprintf("Seccomp flag to %s.", seccompFlag))
+	}
+
+	return *res, nil
+}
+
+// syscallNumbers is the reverse of the generated syscallNames table.
+var syscallNumbers = func() map[string]int {
+	numbers := make(map[string]int, len(syscallNames))
+	for id, name := range syscallNames {
+		numbers[name] = id
+	}
+	return numbers
+}()
+
+// syscallIDToName returns the kernel name of the syscall, empty if unknown.
+func syscallIDToName(id int) string {
+	return syscallNames[id]
+}
+
+// syscallNumber returns the number of the syscall from its kernel name.
+func syscallNumber(name string) (int, bool) {
+	id, ok := syscallNumbers[name]
+	return id, ok
+}
+
+// syscallName returns the kernel name of the syscall or a placeholder for
+// syscalls unknown to the table, newer than maxSyscallID for example.
+func syscallName(id int) string {
+	if name := syscallIDToName(id); name != "" {
+		return name
+	}
+	return unknownSyscall
+}
+
+// syscallLabel formats the syscall with its number like read(0), to
+// correlate with strace or ausyscall outputs.
+func syscallLabel(id int) string {
+	return fmt.Sprintf("%s(%d)", syscallName(id), id)
+}
+
+// syscallEntry is a cell of the results, displayed like read(0) in the human
+// output and structured in JSON so that tooling can map unknown syscalls.
+type syscallEntry struct {
+	Name   string `json:"name,omitempty"`
+	Number int    `json:"number"`
+}
+
+func newSyscallEntry(id int) syscallEntry {
+	return syscallEntry{Name: syscallIDToName(id), Number: id}
+}
+
+func (e syscallEntry) String() string {
+	return syscallLabel(e.Number)
+}
+
+func readSeccompFlag() (SeccompMode, error) {
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), "Seccomp") {
+			line := strings.Split(scanner.Text(), ":")
+			if len(line) < 2 {
+				return 0, errors.New("error in /proc/self/status format, missing colons")
+			}
+			switch strings.TrimSpace(line[1]) {
+			case "0":
+				return SeccompModeDisabled, nil
+			case "1":
+				return SeccompModeStrict, nil
+			case "2":
+				return SeccompModeFilter, nil
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, errors.New("flag Seccomp was not found in /proc/self/status")
+}
+
+// scannedSyscalls returns the syscalls to scan, without the skipped ones and
+// the unassigned numbers.
+func scannedSyscalls() []int {
+	var ids []int
+	for id := 0; id <= maxSyscallID; id++ {
+		if isSkipped(id) || inGap(id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func isSkipped(id int) bool {
+	for _, s := range skippedSyscalls {
+		if id == s {
+			return true
+		}
+	}
+	return false
+}
+
+// syscallScan is modified copy of the amicontained code that you can find here:
+// https://github.com/genuinetools/amicontained/blob/568b0d35e60cb2bfc228ecade8b0ba62c49a906a/main.go#L181
+func syscallScan(ctx context.Context, ids []int, params scanParams) []SyscallScanResult {
+	results := make([]SyscallScanResult, len(ids))
+
+	workers := params.workers
+	if workers <= 0 || workers > len(ids) {
+		workers = len(ids)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], _ = scanSyscall(ids[i], params.timeout)
+				if params.logger != nil {
+					params.logger.Debug("syscall tested", "syscall", syscallLabel(ids[i]), "allowed", results[i].Allowed)
+				}
+			}
+		}()
+	}
+	// stop dispatching syscalls once the context is done
+dispatch:
+	for i := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// calibrate scans the first syscalls with a generous timeout and derives the
+// parameters from the slowest rejection: throttled environments get fewer
+// workers and a longer timeout, fast ones a shorter timeout.
+func calibrate(ctx context.Context, ids []int) ([]SyscallScanResult, scanParams) {
+	results := make([]SyscallScanResult, len(ids))
+	durations := make([]time.Duration, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, id int) {
+			defer wg.Done()
+			var returned bool
+			start := time.Now()
+			results[i], returned = scanSyscall(id, calibrationTimeout)
+			if returned {
+				durations[i] = time.Since(start)
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	var slowest time.Duration
+	for _, d := range durations {
+		slowest = max(slowest, d)
+	}
+
+	params := scanParams{
+		timeout: min(max(slowest*timeoutFactor, minTimeout), maxTimeout).Round(time.Millisecond),
+	}
+	if slowest > slowRejection {
+		params.workers = runtime.NumCPU() * 4
+	}
+	return results, params
+}
+
+// scanSyscall calls the syscall without arguments, returned is false if the
+// syscall did not return before the timeout.
+func scanSyscall(id int, timeout time.Duration) (result SyscallScanResult, returned bool) {
+	// The call may block, so invoke asynchronously and rely on rejections being fast.
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := syscall.Syscall(uintptr(id), 0, 0, 0)
+		errs <- err
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+		returned = true
+	case <-time.After(timeout):
+		// The syscall was allowed, but it didn't return
+	}
+
+	if isBlockedError(err) {
+		return SyscallScanResult{ID: id, Allowed: false}, returned
+	}
+	return SyscallScanResult{ID: id, Allowed: true}, returned
+}
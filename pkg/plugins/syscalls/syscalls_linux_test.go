@@ -0,0 +1,38 @@
+//go:build linux && (amd64 || arm64)
+
+package syscalls
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestSyscallTables(t *testing.T) {
+	for id, name := range syscallNames {
+		if got, ok := syscallNumber(name); !ok || got != id {
+			t.Errorf("syscallNumber(%q) = %d, %t, want %d", name, got, ok, id)
+		}
+	}
+	if got, ok := syscallNumber("openat"); !ok || got != unix.SYS_OPENAT {
+		t.Errorf("syscallNumber(\"openat\") = %d, %t, want %d", got, ok, unix.SYS_OPENAT)
+	}
+	if got := syscallName(9999); got != unknownSyscall {
+		t.Errorf("syscallName(9999) = %q, want %q", got, unknownSyscall)
+	}
+}
+
+// TestScannedSyscallsNamed checks that the scanned range and gaps of the
+// architecture match its names table.
+func TestScannedSyscallsNamed(t *testing.T) {
+	for _, id := range scannedSyscalls() {
+		if syscallIDToName(id) == "" {
+			t.Errorf("scanned syscall %d has no name on this architecture", id)
+		}
+	}
+	for _, id := range skippedSyscalls {
+		if syscallIDToName(id) == "" {
+			t.Errorf("skipped syscall %d has no name on this architecture", id)
+		}
+	}
+}
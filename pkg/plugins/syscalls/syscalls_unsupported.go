@@ -0,0 +1,15 @@
+//go:build !darwin && !(linux && (amd64 || arm64))
+
+package syscalls
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+)
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	return bucket.Results{}, errors.New("syscall scan is not supported on " + runtime.GOOS + "/" + runtime.GOARCH + ", syscall numbers are only known for linux/amd64 and linux/arm64")
+}
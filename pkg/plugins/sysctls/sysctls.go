@@ -0,0 +1,140 @@
+package sysctls
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "sysctls"
+	bucketDescription = "Sysctls reads security relevant kernel parameters and checks if they are writable from the container."
+
+	procSys = "/proc/sys"
+
+	optionExtra = bucketName + ".extra"
+)
+
+var bucketAliases = []string{"sysctl", "kernelparams"}
+
+// sysctl is a kernel parameter, namespaced ones only affect the namespaces of
+// the container while the others apply to the whole node.
+type sysctl struct {
+	name       string
+	namespaced bool
+}
+
+// curatedSysctls are the parameters that weaken the node or help an escape
+// when they can be modified.
+var curatedSysctls = []sysctl{
+	// executed by the kernel as root on the host on core dumps and module
+	// requests, the classic escapes when writable
+	{"kernel.core_pattern", false},
+	{"kernel.modprobe", false},
+	// kernel hardening
+	{"kernel.randomize_va_space", false},
+	{"kernel.kptr_restrict", false},
+	{"kernel.dmesg_restrict", false},
+	{"kernel.perf_event_paranoid", false},
+	{"kernel.unprivileged_bpf_disabled", false},
+	{"kernel.yama.ptrace_scope", false},
+	{"kernel.sysrq", false},
+	{"fs.suid_dumpable", false},
+	{"fs.protected_symlinks", false},
+	{"fs.protected_hardlinks", false},
+	{"vm.mmap_min_addr", false},
+	// user namespaces creation, namespaced per user namespace
+	{"user.max_user_namespaces", true},
+	{"kernel.unprivileged_userns_clone", false},
+	// network namespace parameters
+	{"net.ipv4.ip_forward", true},
+	{"net.ipv4.conf.all.route_localnet", true},
+	{"net.ipv4.ip_unprivileged_port_start", true},
+	{"net.ipv4.ping_group_range", true},
+}
+
+type Bucket struct {
+	sysctls []sysctl
+}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	res.SetHeaders([]string{"sysctl", "value", "writable", "namespaced"})
+	var global, namespaced []string
+	for _, s := range n.sysctls {
+		path := sysctlPath(s.name)
+		value, err := os.ReadFile(path)
+		if err != nil {
+			// the parameter does not exist on this kernel or is hidden
+			continue
+		}
+		writable := isWritable(path)
+		res.AddContent([]interface{}{s.name, strings.Join(strings.Fields(string(value)), " "), writable, s.namespaced})
+		switch {
+		case writable && s.namespaced:
+			namespaced = append(namespaced, s.name)
+		case writable:
+			global = append(global, s.name)
+		}
+	}
+
+	if len(global) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("Kernel parameters of the whole node are writable, /proc/sys might be mounted read-write: %v.", global))
+	}
+	if len(namespaced) > 0 {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment(fmt.Sprintf("Namespaced kernel parameters are writable, they only affect the namespaces of the container unless they are shared with the host: %v.", namespaced))
+	}
+	if len(global) == 0 && len(namespaced) == 0 {
+		res.AddComment("No kernel parameter is writable.")
+	}
+
+	return *res, nil
+}
+
+// sysctlPath converts the dotted name of a parameter to its path.
+func sysctlPath(name string) string {
+	return filepath.Join(procSys, strings.ReplaceAll(name, ".", "/"))
+}
+
+// isWritable opens the file in write only mode without writing anything, the
+// kernel checks the permissions and the read-only mounts at opening.
+func isWritable(path string) bool {
+	fd, err := unix.Open(path, unix.O_WRONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return false
+	}
+	unix.Close(fd)
+	return true
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewSysctlsBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+		Options: map[string]string{
+			optionExtra: "Comma separated list of additional kernel parameters to check, considered global to the node.",
+		},
+	})
+}
+
+func NewSysctlsBucket(config bucket.Config) (*Bucket, error) {
+	sysctls := append([]sysctl{}, curatedSysctls...)
+	for _, name := range config.Options.StringSlice(optionExtra, nil) {
+		sysctls = append(sysctls, sysctl{name: name})
+	}
+	return &Bucket{sysctls: sysctls}, nil
+}
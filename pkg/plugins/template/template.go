@@ -1,6 +1,8 @@
 package template
 
 import (
+	"context"
+
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
@@ -13,7 +15,7 @@ var bucketAliases = []string{"templ", "tp"}
 
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	// executes here the code of your plugin
 	res := bucket.NewResults(bucketName)
 	return *res, nil
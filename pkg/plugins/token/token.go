@@ -1,43 +1,223 @@
 package token
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 const (
 	bucketName        = "token"
-	bucketDescription = "Token checks for the presence of a service account token in the filesystem."
+	bucketDescription = "Token checks for the presence of a service account token in the filesystem and decodes its claims."
 
-	tokenPath = "/run/secrets/kubernetes.io/serviceaccount"
+	tokenFile = "token"
+
+	// ServiceAccountDir is where the kubelet mounts the service account
+	// token of the pod, ServiceAccountToken being the token itself
+	ServiceAccountDir   = "/run/secrets/kubernetes.io/serviceaccount"
+	ServiceAccountToken = ServiceAccountDir + "/" + tokenFile
 )
 
 var bucketAliases = []string{"tokens", "tk"}
 
-type Bucket struct{}
+// DefaultTokenPaths are the service account token directory and common
+// locations of projected tokens with custom audiences, directories are
+// searched for tokens.
+var DefaultTokenPaths = []string{
+	ServiceAccountDir,
+	"/var/run/secrets/tokens",
+	"/var/run/secrets/eks.amazonaws.com/serviceaccount",
+	"/var/run/secrets/pods.eks.amazonaws.com/serviceaccount",
+	"/var/run/secrets/azure/tokens",
+}
+
+type Bucket struct {
+	// claims adds the claims that are not displayed by default
+	claims bool
+	paths  []string
+	// review authenticates the tokens against the API server at apiServer
+	review    bool
+	apiServer string
+}
+
+// Claims are the claims of a JWT, with the service account specific ones of
+// both the legacy secret based tokens and the projected tokens.
+type Claims struct {
+	Issuer   string   `json:"iss"`
+	Subject  string   `json:"sub"`
+	Audience Audience `json:"aud"`
+	Expiry   int64    `json:"exp"`
+	IssuedAt int64    `json:"iat"`
+
+	// projected tokens nest the service account information and the object
+	// they are bound to
+	Kubernetes struct {
+		Namespace      string `json:"namespace"`
+		ServiceAccount struct {
+			Name string `json:"name"`
+		} `json:"serviceaccount"`
+		Pod struct {
+			Name string `json:"name"`
+		} `json:"pod"`
+	} `json:"kubernetes.io"`
+
+	// legacy tokens use flat claims
+	LegacyNamespace      string `json:"kubernetes.io/serviceaccount/namespace"`
+	LegacyServiceAccount string `json:"kubernetes.io/serviceaccount/service-account.name"`
+}
+
+// Audience can be a string or an array of strings in a JWT.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// ServiceAccount returns the name of the service account of the token.
+func (c Claims) ServiceAccount() string {
+	if c.Kubernetes.ServiceAccount.Name != "" {
+		return c.Kubernetes.ServiceAccount.Name
+	}
+	return c.LegacyServiceAccount
+}
+
+// Namespace returns the namespace of the service account of the token.
+func (c Claims) Namespace() string {
+	if c.Kubernetes.Namespace != "" {
+		return c.Kubernetes.Namespace
+	}
+	return c.LegacyNamespace
+}
+
+// ExpiryString formats the expiry, legacy tokens do not expire.
+func (c Claims) ExpiryString() string {
+	if c.Expiry == 0 {
+		return "never"
+	}
+	return time.Unix(c.Expiry, 0).UTC().Format(time.RFC3339)
+}
+
+// IssuedAtString formats the issue date, empty for legacy tokens.
+func (c Claims) IssuedAtString() string {
+	if c.IssuedAt == 0 {
+		return ""
+	}
+	return time.Unix(c.IssuedAt, 0).UTC().Format(time.RFC3339)
+}
+
+// DecodeClaims decodes the payload of a JWT without verifying its signature.
+func DecodeClaims(token string) (Claims, error) {
+	var c Claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return c, errors.New("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return c, fmt.Errorf("failed to decode the token payload: %w", err)
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return c, fmt.Errorf("failed to parse the token claims: %w", err)
+	}
+	return c, nil
+}
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
-	if tokenFolderExist() {
-		res.AddComment("A service account token is mounted.")
 
-		res.SetHeaders([]string{"namespace", "token", "CA"})
+	headers := []string{"path", "namespace", "token", "CA", "serviceAccount", "audience", "issuer", "expiry"}
+	if n.claims {
+		headers = append(headers, "subject", "issuedAt", "boundPod")
+	}
+	if n.review {
+		headers = append(headers, "username", "groups", bucket.ErrorHeader)
+		if n.apiServer == "" {
+			res.AddComment("The API server address is unknown, the tokens can only be reviewed from inside a cluster.")
+		}
+	}
+	res.SetHeaders(headers)
 
-		ns, err := readMountedData("namespace")
+	// the same token can be reached by several paths, like /var/run that is
+	// usually a link to /run
+	seen := make(map[string]bool)
+	valid := 0
+	for _, path := range tokenFiles(n.paths) {
+		content, err := os.ReadFile(path)
 		if err != nil {
-			return bucket.Results{}, err
+			res.AddComment(fmt.Sprintf("Failed to read %s: %s", path, err))
+			continue
 		}
-		t, err := readMountedData("token")
+		t := strings.TrimSpace(string(content))
+		claims, err := DecodeClaims(t)
 		if err != nil {
-			return bucket.Results{}, err
+			// only the files named token are expected to be tokens
+			if filepath.Base(path) != tokenFile {
+				continue
+			}
+			// a malformed token should not hide the raw data
+			res.AddComment(fmt.Sprintf("Failed to decode the claims of %s: %s", path, err))
 		}
-		ca, err := readMountedData("ca.crt")
-		if err != nil {
-			return bucket.Results{}, err
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		// the namespace and the CA are only mounted next to the service
+		// account tokens
+		dir := filepath.Dir(path)
+		ns := readOptional(filepath.Join(dir, "namespace"))
+		ca := readOptional(filepath.Join(dir, "ca.crt"))
+		if claims.Namespace() != "" && ns != "" && claims.Namespace() != ns {
+			res.AddComment(fmt.Sprintf("The namespace %q of %s differs from the mounted namespace %q.", claims.Namespace(), path, ns))
+		}
+
+		row := []interface{}{path, ns, string(content), ca, claims.ServiceAccount(), []string(claims.Audience), claims.Issuer, claims.ExpiryString()}
+		if n.claims {
+			row = append(row, claims.Subject, claims.IssuedAtString(), claims.Kubernetes.Pod.Name)
 		}
+		if !n.review {
+			res.AddContent(row)
+			continue
+		}
+		var user authenticationv1.UserInfo
+		err = errors.New("API server address unknown")
+		if n.apiServer != "" {
+			user, err = reviewToken(ctx, n.apiServer, t, ca)
+		}
+		if err == nil {
+			valid++
+		}
+		res.AddContentWithError(append(row, user.Username, user.Groups), err)
+	}
 
-		res.AddContent([]interface{}{ns, t, ca})
+	if len(seen) > 0 {
+		res.AddComment(fmt.Sprintf("%d distinct tokens were found.", len(seen)))
+		if n.review {
+			res.AddComment(fmt.Sprintf("%d of them were accepted by the API server.", valid))
+		}
 	} else {
 		res.AddComment("No service account token was found in the local filesystem")
 	}
@@ -57,19 +237,81 @@ func Register(b *bucket.Buckets) {
 	})
 }
 
-func NewTokenBucket(_ bucket.Config) (*Bucket, error) {
-	return &Bucket{}, nil
+func NewTokenBucket(config bucket.Config) (*Bucket, error) {
+	paths := config.TokenPaths
+	if len(paths) == 0 {
+		paths = DefaultTokenPaths
+	}
+	b := &Bucket{
+		claims: config.TokenClaims,
+		paths:  paths,
+		review: config.TokenReview,
+	}
+	// the in-cluster address of the API server, that the tokens are meant for
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host != "" && port != "" {
+		b.apiServer = "https://" + net.JoinHostPort(host, port)
+	}
+	return b, nil
 }
 
-func tokenFolderExist() bool {
-	_, err := os.Stat(tokenPath)
-	return !os.IsNotExist(err)
+// reviewToken authenticates with the token to the API server and returns the
+// user it maps to, which proves that the token is valid and not expired. The
+// CA is the one mounted next to the token, the system roots are used if empty.
+func reviewToken(ctx context.Context, apiServer string, token string, ca string) (authenticationv1.UserInfo, error) {
+	config := &rest.Config{
+		Host:        apiServer,
+		BearerToken: token,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData: []byte(ca),
+		},
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return authenticationv1.UserInfo{}, err
+	}
+	review, err := client.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{})
+	if err != nil {
+		return authenticationv1.UserInfo{}, err
+	}
+	return review.Status.UserInfo, nil
+}
+
+// tokenFiles returns the candidate token files, the files of the candidate
+// directories or the candidate files themselves.
+func tokenFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			// projected volumes files are links to a hidden ..data directory
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			file := filepath.Join(path, e.Name())
+			if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+				files = append(files, file)
+			}
+		}
+	}
+	return files
 }
 
-func readMountedData(data string) (string, error) {
-	b, err := os.ReadFile(tokenPath + "/" + data)
+func readOptional(path string) string {
+	b, err := os.ReadFile(path)
 	if err != nil {
-		return "", err
+		return ""
 	}
-	return string(b), nil
+	return string(b)
 }
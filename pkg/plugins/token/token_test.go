@@ -0,0 +1,47 @@
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+)
+
+func TestReviewToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/apis/authentication.k8s.io/v1/selfsubjectreviews" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		review := authenticationv1.SelfSubjectReview{
+			Status: authenticationv1.SelfSubjectReviewStatus{
+				UserInfo: authenticationv1.UserInfo{
+					Username: "system:serviceaccount:default:default",
+					Groups:   []string{"system:serviceaccounts", "system:authenticated"},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	defer server.Close()
+
+	user, err := reviewToken(context.Background(), server.URL, "valid", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "system:serviceaccount:default:default" || len(user.Groups) != 2 {
+		t.Errorf("reviewToken() = %+v", user)
+	}
+
+	if _, err := reviewToken(context.Background(), server.URL, "expired", ""); err == nil {
+		t.Error("reviewToken() accepted a token rejected by the API server")
+	}
+}
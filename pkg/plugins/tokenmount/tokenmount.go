@@ -0,0 +1,89 @@
+package tokenmount
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/plugins/token"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	bucketName        = "tokenmount"
+	bucketDescription = "TokenMount checks the integrity of the service account token mount, looking for writable directories or files."
+
+	tokenPath = token.ServiceAccountDir
+)
+
+var bucketAliases = []string{"tokenmounts", "satokenmount"}
+
+var tokenFiles = []string{"token", "ca.crt", "namespace"}
+
+type Bucket struct{}
+
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	if _, err := os.Stat(tokenPath); err != nil {
+		res.AddComment("No service account token was found in the local filesystem.")
+		return *res, nil
+	}
+
+	// the token directory, its files and the parent directories
+	paths := []string{tokenPath}
+	for _, f := range tokenFiles {
+		paths = append(paths, filepath.Join(tokenPath, f))
+	}
+	for dir := filepath.Dir(tokenPath); dir != "/"; dir = filepath.Dir(dir) {
+		paths = append(paths, dir)
+	}
+
+	res.SetHeaders([]string{"path", "writable"})
+	var tokenWritable, parentsWritable []string
+	for i, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		// access does not modify anything and fails on read-only mounts
+		writable := unix.Access(path, unix.W_OK) == nil
+		res.AddContent([]interface{}{path, writable})
+		if !writable {
+			continue
+		}
+		if i <= len(tokenFiles) {
+			tokenWritable = append(tokenWritable, path)
+		} else {
+			parentsWritable = append(parentsWritable, path)
+		}
+	}
+
+	if len(tokenWritable) > 0 {
+		res.RaiseSeverity(bucket.SeverityHigh)
+		res.AddComment(fmt.Sprintf("The token mount is writable, the token could be swapped or the CA tampered with to intercept the API clients traffic: %v.", tokenWritable))
+	}
+	if len(parentsWritable) > 0 {
+		res.AddComment(fmt.Sprintf("Parent directories of the token mount are writable, the mount itself can't be replaced without unmounting it: %v.", parentsWritable))
+	}
+
+	return *res, nil
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewTokenMountBucket(config)
+		},
+		SideEffects:   false,
+		RequireClient: false,
+	})
+}
+
+func NewTokenMountBucket(_ bucket.Config) (*Bucket, error) {
+	return &Bucket{}, nil
+}
@@ -1,21 +1,42 @@
 package userid
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
 	"os/user"
+	"strings"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
 const (
 	bucketName        = "userid"
-	bucketDescription = "UserID retrieves UID, GID and their corresponding names."
+	bucketDescription = "UserID retrieves UID, GID, their corresponding names, the supplementary groups and the NoNewPrivs flag of the process."
+
+	procStatus = "/proc/self/status"
 )
 
-var bucketAliases = []string{"userids", "id"}
+var bucketAliases = []string{"userids", "id", "userinfo"}
+
+// idKinds are the IDs of the Uid and Gid lines of /proc/self/status, in order.
+var idKinds = []string{"real", "effective", "saved", "filesystem"}
+
+// status is the identity of the process read from /proc/self/status.
+type status struct {
+	uids       []string
+	gids       []string
+	groups     []string
+	noNewPrivs bool
+	// kernels older than 4.10 do not report the NoNewPrivs flag
+	noNewPrivsKnown bool
+}
 
 type Bucket struct{}
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	usr, err := user.Current()
 	if err != nil {
 		return bucket.Results{}, err
@@ -26,21 +47,92 @@ func (n Bucket) Run() (bucket.Results, error) {
 		return bucket.Results{}, err
 	}
 
-	// the following will always panic without CGO enabled
-	// "panic: user: GroupIds requires cgo"
+	// usr.GroupIds() requires cgo, the supplementary groups are read from
+	// /proc/self/status instead
+	res := bucket.NewResults(bucketName)
+	file, err := os.Open(procStatus)
+	if err != nil {
+		res.SetHeaders([]string{"userID", "userName", "groupID", "groupName", "homeDir"})
+		res.AddContent([]interface{}{usr.Uid, usr.Username, usr.Gid, mainGroup.Name, usr.HomeDir})
+		res.AddComment(fmt.Sprintf("Failed to read the identity of the process: %s.", err))
+		return *res, nil
+	}
+	defer file.Close()
+	s, err := parseStatus(file)
+	if err != nil {
+		return bucket.Results{}, err
+	}
+
+	groups := make([]string, 0, len(s.groups))
+	for _, gid := range s.groups {
+		if g, err := user.LookupGroupId(gid); err == nil {
+			groups = append(groups, fmt.Sprintf("%s(%s)", gid, g.Name))
+		} else {
+			groups = append(groups, gid)
+		}
+	}
+	res.SetHeaders([]string{"userID", "userName", "groupID", "groupName", "homeDir", "groups"})
+	res.AddContent([]interface{}{usr.Uid, usr.Username, usr.Gid, mainGroup.Name, usr.HomeDir, groups})
 
-	// otherGroups, err := usr.GroupIds()
-	// if err != nil {
-	//     return bucket.Results{}, err
-	// }
+	ids := bucket.NewResults("ids")
+	ids.SetHeaders(append([]string{"id"}, idKinds...))
+	ids.AddContent([]interface{}{"uid", s.uids[0], s.uids[1], s.uids[2], s.uids[3]})
+	ids.AddContent([]interface{}{"gid", s.gids[0], s.gids[1], s.gids[2], s.gids[3]})
+	res.AddSubResults(*ids)
 
-	res := bucket.NewResults(bucketName)
-	res.SetHeaders([]string{"userID", "userName", "groupID", "groupName", "homeDir"})
-	res.AddContent([]interface{}{usr.Uid, usr.Username, usr.Gid, mainGroup.Name, usr.HomeDir})
+	if s.uids[1] == "0" {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("The process is running as root.")
+	}
+	if s.uids[0] != s.uids[1] {
+		res.AddComment("The real and effective UIDs differ, the process might be running a setuid binary.")
+	}
+	switch {
+	case !s.noNewPrivsKnown:
+		res.AddComment(fmt.Sprintf("The NoNewPrivs flag is unknown, the kernel does not report it in %s.", procStatus))
+	case s.noNewPrivs:
+		res.AddComment("The NoNewPrivs flag is set, executing setuid binaries or binaries with file capabilities can't raise the privileges.")
+	default:
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("The NoNewPrivs flag is not set, executing setuid binaries or binaries with file capabilities can raise the privileges, allowPrivilegeEscalation is not disabled for the container.")
+	}
 
 	return *res, nil
 }
 
+// parseStatus reads the IDs, the supplementary groups and the NoNewPrivs flag
+// from the content of /proc/self/status.
+func parseStatus(r io.Reader) (status, error) {
+	var s status
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		switch key {
+		case "Uid":
+			s.uids = fields
+		case "Gid":
+			s.gids = fields
+		case "Groups":
+			s.groups = fields
+		case "NoNewPrivs":
+			s.noNewPrivsKnown = true
+			s.noNewPrivs = len(fields) == 1 && fields[0] == "1"
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return status{}, err
+	}
+
+	if len(s.uids) != len(idKinds) || len(s.gids) != len(idKinds) {
+		return status{}, fmt.Errorf("lines Uid and Gid of %s must have %d fields", procStatus, len(idKinds))
+	}
+	return s, nil
+}
+
 func Register(b *bucket.Buckets) {
 	b.Register(bucket.Bucket{
 		Name:        bucketName,
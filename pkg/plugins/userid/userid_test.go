@@ -0,0 +1,51 @@
+package userid
+
+import (
+	"strings"
+	"testing"
+)
+
+const testStatus = `Name:	kdigger
+Umask:	0022
+State:	R (running)
+Uid:	1000	0	1000	0
+Gid:	1000	1000	1000	1000
+Groups:	4 24 1000 
+NoNewPrivs:	0
+Seccomp:	2
+`
+
+func TestParseStatus(t *testing.T) {
+	s, err := parseStatus(strings.NewReader(testStatus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.uids[0] != "1000" || s.uids[1] != "0" || s.gids[3] != "1000" {
+		t.Errorf("parseStatus() uids = %v, gids = %v", s.uids, s.gids)
+	}
+	if len(s.groups) != 3 || s.groups[2] != "1000" {
+		t.Errorf("parseStatus() groups = %v, want [4 24 1000]", s.groups)
+	}
+	if s.noNewPrivs {
+		t.Error("parseStatus() noNewPrivs = true, want false")
+	}
+
+	s, err = parseStatus(strings.NewReader(strings.Replace(testStatus, "NoNewPrivs:\t0", "NoNewPrivs:\t1", 1)))
+	if err != nil || !s.noNewPrivs {
+		t.Errorf("parseStatus() noNewPrivs = %v, %v, want true", s.noNewPrivs, err)
+	}
+}
+
+func TestParseStatusMissing(t *testing.T) {
+	// kernels older than 4.10 do not report the flag, it is unknown
+	s, err := parseStatus(strings.NewReader(strings.Replace(testStatus, "NoNewPrivs:\t0\n", "", 1)))
+	if err != nil {
+		t.Fatalf("parseStatus() without NoNewPrivs error = %v", err)
+	}
+	if s.noNewPrivsKnown || s.uids[1] != "0" || len(s.groups) != 3 {
+		t.Errorf("parseStatus() without NoNewPrivs = %+v, want the ids and an unknown flag", s)
+	}
+	if _, err := parseStatus(strings.NewReader("Uid:\t0\n")); err == nil {
+		t.Error("parseStatus() with a truncated Uid line should fail")
+	}
+}
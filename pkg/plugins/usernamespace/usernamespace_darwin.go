@@ -1,11 +1,12 @@
 package usernamespace
 
 import (
+	"context"
 	"errors"
 
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	return bucket.Results{}, errors.New("usernamespace detection is not supported on macOS x86")
 }
@@ -1,11 +1,13 @@
 package usernamespace
 
 import (
+	"context"
+
 	"github.com/genuinetools/bpfd/proc"
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	userNS, userMapping := proc.GetUserNamespaceInfo(0)
 
 	res := bucket.NewResults(bucketName)
@@ -1,6 +1,8 @@
 package version
 
 import (
+	"context"
+
 	"github.com/quarkslab/kdigger/pkg/bucket"
 )
 
@@ -15,7 +17,7 @@ type Bucket struct {
 	config bucket.Config
 }
 
-func (n Bucket) Run() (bucket.Results, error) {
+func (n Bucket) Run(_ context.Context) (bucket.Results, error) {
 	res := bucket.NewResults(bucketName)
 	v, err := n.config.Client.Discovery().ServerVersion()
 	if err != nil {
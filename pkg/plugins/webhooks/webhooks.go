@@ -0,0 +1,138 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/quarkslab/kdigger/pkg/bucket"
+	"github.com/quarkslab/kdigger/pkg/egress"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	bucketName        = "webhooks"
+	bucketDescription = "Webhooks lists the admission webhooks and probes their endpoints reachability from the pod."
+
+	// port used by the API server when the service reference has no port
+	defaultServicePort = 443
+)
+
+var bucketAliases = []string{"webhook", "wh"}
+
+type Bucket struct {
+	config bucket.Config
+}
+
+type webhook struct {
+	name     string
+	kind     string
+	endpoint string
+}
+
+func (n Bucket) Run(ctx context.Context) (bucket.Results, error) {
+	res := bucket.NewResults(bucketName)
+
+	var webhooks []webhook
+	var listErrors int
+
+	validating, err := n.config.Client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		listErrors++
+		res.AddComment(fmt.Sprintf("Failed to list validating webhooks: %s", err))
+	} else {
+		for _, conf := range validating.Items {
+			for _, w := range conf.Webhooks {
+				webhooks = append(webhooks, webhook{w.Name, "validating", endpoint(w.ClientConfig)})
+			}
+		}
+	}
+
+	mutating, err := n.config.Client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		listErrors++
+		res.AddComment(fmt.Sprintf("Failed to list mutating webhooks: %s", err))
+	} else {
+		for _, conf := range mutating.Items {
+			for _, w := range conf.Webhooks {
+				webhooks = append(webhooks, webhook{w.Name, "mutating", endpoint(w.ClientConfig)})
+			}
+		}
+	}
+
+	if listErrors == 2 {
+		return bucket.Results{}, fmt.Errorf("failed to list any webhook configurations: %w", err)
+	}
+
+	addresses := make([]string, len(webhooks))
+	for i, w := range webhooks {
+		addresses[i] = w.endpoint
+	}
+	probes := egress.TCPAll(ctx, addresses, egress.DefaultTimeout)
+
+	res.SetHeaders([]string{"name", "type", "endpoint", "reachable", bucket.ErrorHeader})
+	reachable := 0
+	for i, w := range webhooks {
+		if probes[i].Reachable {
+			reachable++
+		}
+		res.AddContentWithError([]interface{}{w.name, w.kind, w.endpoint, probes[i].Reachable}, probes[i].Error)
+	}
+	res.AddComment(fmt.Sprintf("%d out of %d webhooks endpoints are reachable from the pod.", reachable, len(webhooks)))
+	if reachable > 0 {
+		res.RaiseSeverity(bucket.SeverityLow)
+		res.AddComment("Reachable webhooks services might be used as SSRF or lateral movement targets.")
+	}
+
+	return *res, nil
+}
+
+// endpoint returns the "host:port" address used by the API server to reach
+// the webhook, either from its URL or from its service reference.
+func endpoint(config admissionv1.WebhookClientConfig) string {
+	if config.Service != nil {
+		port := int32(defaultServicePort)
+		if config.Service.Port != nil {
+			port = *config.Service.Port
+		}
+		host := fmt.Sprintf("%s.%s.svc", config.Service.Name, config.Service.Namespace)
+		return net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	if config.URL != nil {
+		u, err := url.Parse(*config.URL)
+		if err != nil {
+			return *config.URL
+		}
+		if u.Port() != "" {
+			return u.Host
+		}
+		// the URL scheme must be https for webhooks
+		return net.JoinHostPort(u.Hostname(), strconv.Itoa(defaultServicePort))
+	}
+	return ""
+}
+
+func Register(b *bucket.Buckets) {
+	b.Register(bucket.Bucket{
+		Name:        bucketName,
+		Description: bucketDescription,
+		Aliases:     bucketAliases,
+		Factory: func(config bucket.Config) (bucket.Interface, error) {
+			return NewWebhooksBucket(config)
+		},
+		SideEffects:   true,
+		RequireClient: true,
+	})
+}
+
+func NewWebhooksBucket(config bucket.Config) (*Bucket, error) {
+	if config.Client == nil {
+		return nil, bucket.ErrMissingClient
+	}
+	return &Bucket{
+		config: config,
+	}, nil
+}